@@ -0,0 +1,34 @@
+// watch.go provides long-running watches that survive API server timeouts.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// WatchFunc starts a watch with the given options (e.g. Pods(ns).Watch)
+type WatchFunc func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+
+// NewRetryWatcher starts a watch at resourceVersion (from a preceding List) that is
+// transparently restarted when the server closes it. Only the label and field
+// selectors of selectors are used. The watch ends when ctx is cancelled or the
+// resourceVersion is too old.
+func NewRetryWatcher(ctx context.Context, resourceVersion string, selectors metav1.ListOptions, fn WatchFunc) (watch.Interface, error) {
+	lw := &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			opts.LabelSelector = selectors.LabelSelector
+			opts.FieldSelector = selectors.FieldSelector
+			return fn(ctx, opts)
+		},
+	}
+	w, err := watchtools.NewRetryWatcherWithContext(ctx, resourceVersion, lw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start watch: %w", err)
+	}
+	return w, nil
+}
@@ -60,7 +60,7 @@ func runAttach(cmd *cobra.Command, args []string) {
 	fmt.Printf("Attaching to %s session %s/%s...\n", mode, nodeName, volumeName)
 
 	// Convert to pod name: <node>-<volume>
-	podName := fmt.Sprintf("%s-%s", nodeName, volumeName)
+	podName := volume.SessionPodName(nodeName, volumeName)
 
 	// Wait for pod to be ready
 	fmt.Println("Waiting for pod to be ready...")
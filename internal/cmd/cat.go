@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat <node>/<volume>:<path>",
+	Short: "Print a file in a volume",
+	Long: `Print the contents of a file in a volume, without starting a session.
+
+The file is read from the volume's session if one is running, or else from a
+short-lived helper pod (see 'sgs ls --help'). Status messages go to stderr,
+so the output can be redirected.
+
+Examples:
+  # Print a config file
+  sgs cat ferrari/os-vol:/home/user/config.yaml
+
+  # Show the end of a training log
+  sgs cat ferrari/data:/logs/train.log | tail -n 20`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCat,
+}
+
+func runCat(cmd *cobra.Command, args []string) {
+	// Use InterruptibleContext so the helper pod is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	if !strings.Contains(args[0], ":") {
+		exitWithError("a file path is required: <node>/<volume>:<path>", nil)
+	}
+
+	browser, p := openBrowser(ctx, args[0])
+	err := browser.Cat(ctx, p, os.Stdout)
+	browser.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+}
@@ -13,12 +13,18 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var cpForce bool
+var (
+	cpForce    bool   // --force flag
+	cpVerify   bool   // --verify flag
+	cpCompress string // --compress flag
+	cpBwLimit  string // --bwlimit flag
+	cpRelay    bool   // --relay flag
+)
 
 var cpCmd = &cobra.Command{
 	Use:   "cp <source> <destination>",
-	Short: "Copy volumes or files/directories between volumes",
-	Long: `Copy volumes or files/directories between volumes.
+	Short: "Copy volumes, or files/directories between volumes and your machine",
+	Long: `Copy volumes, or files/directories between volumes and your machine.
 
 Volume copy (entire volume):
   sgs cp <node>/<volume> <node>/<volume>
@@ -32,7 +38,37 @@ File/directory copy (specific paths):
   - Both source and destination volumes must exist
   - For OS volumes, paths are relative to the rootfs (handled internally)
 
-Note: Source and destination must BOTH have paths (file/directory copy) or NEITHER have paths (volume copy).
+Upload and download (local paths):
+  sgs cp <local-path> <node>/<volume>[:<path>]
+  sgs cp <node>/<volume>[:<path>] <local-path>
+  - Copies a file or directory between your machine and a volume
+  - Local paths must be absolute or start with ./, ../ or ~/
+  - If the destination is an existing directory, the source is copied into it
+  - Without a volume path, the volume root is used
+  - File permissions and modification times are preserved
+
+Verification (--verify, copies between volumes):
+  - Cross-node copies hash the tar stream as it passes through sgs and compare
+    it with what the destination received
+  - Every copied file is then checksummed (SHA-256) on both sides
+  - Missing or differing files are listed and sgs exits with a non-zero code
+
+Copies between nodes:
+  - The destination copy pod pulls the data directly from the source copy pod,
+    authenticated with a one-time token, so it never passes through your machine
+  - If the pods can't reach each other, sgs relays the data instead (use
+    --relay to always relay)
+
+Slow links (--compress, --bwlimit, copies between nodes):
+  - --compress gzip|zstd compresses in the source pod and decompresses in the
+    destination pod, so less data passes through sgs (zstd is faster; its
+    copy pods use the larger archlinux:base image, which ships zstd but no
+    nc, so the data is always relayed through sgs)
+  - --bwlimit caps the transfer rate in bytes per second (e.g. 10M, 512Ki);
+    the data is then always relayed through sgs
+  - Progress shows throughput, and the estimated time left for uncompressed copies
+
+Note: Between volumes, source and destination must BOTH have paths (file/directory copy) or NEITHER have paths (volume copy).
 
 Examples:
   # Copy entire volume (creates destination)
@@ -48,7 +84,19 @@ Examples:
   sgs cp ferrari/os-vol:/home/user/code ferrari/data:/backup/code
 
   # Copy between different nodes
-  sgs cp ferrari/data:/models porsche/data:/models`,
+  sgs cp ferrari/data:/models porsche/data:/models
+
+  # Copy and verify checksums
+  sgs cp --verify ferrari/data:/models porsche/data:/models
+
+  # Copy over a slow VPN link
+  sgs cp --compress zstd --bwlimit 10M ferrari/data:/models porsche/data:/models
+
+  # Upload a dataset from your machine
+  sgs cp ./datasets/mnist ferrari/data:/datasets/
+
+  # Download results to the current directory
+  sgs cp ferrari/os-vol:/home/user/results .`,
 	Args: cobra.ExactArgs(2),
 	Run:  runCp,
 }
@@ -56,6 +104,10 @@ Examples:
 func init() {
 	rootCmd.AddCommand(cpCmd)
 	cpCmd.Flags().BoolVarP(&cpForce, "force", "f", false, "Skip confirmation prompt (volume copy only)")
+	cpCmd.Flags().BoolVar(&cpVerify, "verify", false, "Verify file checksums after copying between volumes")
+	cpCmd.Flags().StringVar(&cpCompress, "compress", "", "Compress cross-node copies in transit (gzip|zstd)")
+	cpCmd.Flags().StringVar(&cpBwLimit, "bwlimit", "", "Limit cross-node copies to this many bytes per second (e.g. 10M)")
+	cpCmd.Flags().BoolVar(&cpRelay, "relay", false, "Relay cross-node copies through sgs instead of pod to pod")
 }
 
 func runCp(cmd *cobra.Command, args []string) {
@@ -65,6 +117,31 @@ func runCp(cmd *cobra.Command, args []string) {
 	ctx, cancel := cleanup.InterruptibleContext(context.Background())
 	defer cancel()
 
+	srcLocal := volume.IsLocalPath(args[0])
+	dstLocal := volume.IsLocalPath(args[1])
+	if srcLocal || dstLocal {
+		if cpVerify {
+			exitWithError("--verify is only supported for copies between volumes", nil)
+		}
+		if cpCompress != "" || cpBwLimit != "" || cpRelay {
+			exitWithError("--compress, --bwlimit and --relay are only supported for copies between volumes", nil)
+		}
+		runCpLocal(ctx, args[0], args[1], srcLocal, dstLocal)
+		return
+	}
+
+	if err := volume.ValidateCompression(cpCompress); err != nil {
+		exitWithError("", err)
+	}
+	var bwLimit int64
+	if cpBwLimit != "" {
+		limit, err := volume.ParseBandwidth(cpBwLimit)
+		if err != nil {
+			exitWithError("", err)
+		}
+		bwLimit = limit
+	}
+
 	// Parse source and destination using the parser that handles paths
 	srcPath, err := volume.ParseCopyPath(args[0])
 	if err != nil {
@@ -84,6 +161,11 @@ func runCp(cmd *cobra.Command, args []string) {
 		exitWithError("invalid copy format: source and destination must both have paths (file/directory copy) or neither have paths (volume copy)", nil)
 	}
 
+	// Same-node copies run in a single pod and never pass through sgs
+	if srcPath.NodeName == dstPath.NodeName && (cpCompress != "" || bwLimit > 0 || cpRelay) {
+		fmt.Println("Note: --compress, --bwlimit and --relay only apply to copies between nodes and are ignored")
+	}
+
 	k8sClient, err := client.New()
 	if err != nil {
 		exitWithError("failed to create client", err)
@@ -96,6 +178,10 @@ func runCp(cmd *cobra.Command, args []string) {
 		DstNode:   dstPath.NodeName,
 		DstVolume: dstPath.VolumeName,
 		DstPath:   dstPath.Path,
+		Verify:    cpVerify,
+		Compress:  cpCompress,
+		BwLimit:   bwLimit,
+		Relay:     cpRelay,
 	}
 
 	if srcHasPath {
@@ -141,3 +227,41 @@ func runCp(cmd *cobra.Command, args []string) {
 		exitWithError("", err)
 	}
 }
+
+// runCpLocal uploads or downloads between the local machine and a volume
+func runCpLocal(ctx context.Context, src, dst string, srcLocal, dstLocal bool) {
+	if srcLocal && dstLocal {
+		exitWithError("both source and destination are local paths; use cp instead", nil)
+	}
+
+	remote := dst
+	if dstLocal {
+		remote = src
+	}
+	remotePath, err := volume.ParseCopyPath(remote)
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid volume path: %s (local paths must be absolute or start with ./, ../ or ~/)", remote), err)
+	}
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if srcLocal {
+		fmt.Printf("Uploading %s to %s...\n", src, dst)
+		err = volume.Upload(ctx, k8sClient, src, remotePath.NodeName, remotePath.VolumeName, remotePath.Path)
+	} else {
+		fmt.Printf("Downloading %s to %s...\n", src, dst)
+		err = volume.Download(ctx, k8sClient, remotePath.NodeName, remotePath.VolumeName, remotePath.Path, dst)
+	}
+	if err != nil {
+		// If context was cancelled (interrupt), signal handler already cleaned up and will exit
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Successfully copied %s to %s\n", src, dst)
+}
@@ -4,29 +4,39 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
 	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/secret"
 	"github.com/bacchus-snu/sgs-cli/internal/volume"
 	"github.com/spf13/cobra"
 )
 
 var (
-	createSize  string
-	createImage string
+	createSize       string
+	createImage      string
+	createSetup      string // --setup flag (script path)
+	createDockerfile string // --from-dockerfile flag
 
 	// Session flags
-	sessionRunMode bool  // --run flag
-	sessionGPUNum  int   // --gpu-num flag
-	sessionGPUMem  int64 // --gpu-mem flag (MiB)
-	sessionPinCPU  int64 // --pin-cpu flag (cores)
-	sessionPinMem  int64 // --pin-mem flag (bytes)
+	sessionRunMode bool   // --run flag
+	sessionGPUNum  int    // --gpu-num flag
+	sessionGPUMem  int64  // --gpu-mem flag (MiB)
+	sessionGPUType string // --gpu-type flag
+	sessionPinCPU  int64  // --pin-cpu flag (cores)
+	sessionPinMem  int64  // --pin-mem flag (bytes)
 	sessionCmd     []string
 	sessionMounts  []string
-	sessionAttach  bool // --attach flag
+	sessionAttach  bool     // --attach flag
+	sessionEnv     []string // --env flag (KEY=VALUE)
+	sessionEnvFile []string // --env-file flag
+	sessionSecrets []string // --secret flag (<name>[:<path>])
+	sessionNode    string   // --node flag
 )
 
 var createCmd = &cobra.Command{
@@ -51,6 +61,18 @@ The --image flag can be used in two ways:
   - --image <custom-image>: Use a specific container image
   - --image (without value): Use the default image (nvidia/cuda:12.5.0-base-ubuntu22.04)
 
+An OS volume can be provisioned when it is created, so that it is ready to
+use with your packages installed:
+  - --setup <script>: Run a local script (apt, pip, conda installs, ...) in
+    the volume. A #! line picks the interpreter; the default is sh.
+  - --from-dockerfile <Dockerfile>: Use the FROM image and run the RUN
+    instructions, with ENV, ARG and WORKDIR applied. The final ENV and
+    WORKDIR are saved to /etc/profile.d/sgs-dockerfile.sh for login shells.
+    COPY and ADD are not supported; copy files in with 'sgs cp' afterwards.
+The output is shown as it runs. The volume shows as SettingUp until the
+script succeeds; if it fails, the volume is kept as SetupFailed so you can
+inspect it in a session.
+
 Examples:
   # Create an OS volume with default image
   sgs create volume ferrari/os-volume --image
@@ -62,13 +84,19 @@ Examples:
   sgs create volume ferrari/data-vol --size 100Gi
 
   # Create with custom size
-  sgs create volume ferrari/os-volume --image --size 50Gi`,
+  sgs create volume ferrari/os-volume --image --size 50Gi
+
+  # Create an OS volume and install packages with a setup script
+  sgs create volume ferrari/os-volume --image ubuntu:22.04 --setup setup.sh
+
+  # Create an OS volume from a Dockerfile
+  sgs create volume ferrari/os-volume --from-dockerfile ./Dockerfile`,
 	Args: cobra.ExactArgs(1),
 	Run:  runCreateVolume,
 }
 
 var createSessionCmd = &cobra.Command{
-	Use:     "session <node>/<volume>",
+	Use:     "session <node>/<volume> | <volume> --node <node>|auto",
 	Aliases: []string{"sessions", "se"},
 	Short:   "Create a session on an OS volume (se)",
 	Long: `Create a session on an OS volume.
@@ -83,9 +111,22 @@ With --run flag: Creates a run session for GPU workloads.
   - Optional --command flag for batch execution
   - CPU/memory automatically calculated based on GPU count
   - Use --pin-cpu and --pin-mem to pin resources
+  - Use --gpu-type to only use GPUs of a type, e.g. A100 (see 'sgs describe
+    node <node> --gpus' for the GPU types of a node)
+
+With --node auto, sgs picks the node for a run session: among the nodes
+holding a volume of the given name, the one with the most free GPUs and GPU
+memory that fits --gpu-num, --gpu-mem and --gpu-type (see 'sgs find-node').
 
 You can mount volumes using the --mount flag (both OS and data volumes supported).
 
+Environment variables are set with --env KEY=VALUE or --env-file (a .env
+file). They are stored in the session's pod, which others in the workspace
+can read, so keep API keys (WandB, HuggingFace, ...) in secrets instead (see
+'sgs secret create') and inject them with --secret:
+  - --secret <name>: Set each key of the secret as an environment variable
+  - --secret <name>:<path>: Mount each key as a read-only file in <path>
+
 Examples:
   # Start an edit session
   sgs create session ferrari/os-volume
@@ -102,6 +143,18 @@ Examples:
   # Start a run session with batch command
   sgs create session ferrari/os-volume --run --gpu-num 2 --gpu-mem 16384 --command "python train.py"
 
+  # Start a run session on A100 GPUs only
+  sgs create session ferrari/os-volume --run --gpu-num 1 --gpu-mem 40000 --gpu-type A100
+
+  # Start a run session with an API key from a secret and a variable
+  sgs create session ferrari/os-volume --run --gpu-num 1 --gpu-mem 8192 --secret wandb --env WANDB_PROJECT=llm --command "python train.py"
+
+  # Mount a secret as files
+  sgs create session ferrari/os-volume --secret hf-token:/root/.secrets
+
+  # Start a run session on the best node holding a volume named os-volume
+  sgs create session os-volume --node auto --run --gpu-num 2 --gpu-mem 20000
+
   # Start a run session with pinned resources
   sgs create session ferrari/os-volume --run --gpu-num 1 --gpu-mem 8192 --pin-cpu 8 --pin-mem 34359738368`,
 	Args: cobra.ExactArgs(1),
@@ -115,15 +168,22 @@ func init() {
 	createVolumeCmd.Flags().StringVar(&createSize, "size", "", "Storage size (default: 10Gi)")
 	createVolumeCmd.Flags().StringVar(&createImage, "image", "", "Container image for OS volume (default: "+volume.DefaultImage+")")
 	createVolumeCmd.Flags().Lookup("image").NoOptDefVal = volume.DefaultImage
+	createVolumeCmd.Flags().StringVar(&createSetup, "setup", "", "Setup script to run in the new OS volume")
+	createVolumeCmd.Flags().StringVar(&createDockerfile, "from-dockerfile", "", "Dockerfile to build the OS volume from (sets the image)")
 
 	createSessionCmd.Flags().BoolVar(&sessionRunMode, "run", false, "Create a run session (with GPU)")
 	createSessionCmd.Flags().BoolVar(&sessionAttach, "attach", false, "Attach to the session after creation")
 	createSessionCmd.Flags().IntVar(&sessionGPUNum, "gpu-num", 0, "Number of GPUs (required for run mode)")
 	createSessionCmd.Flags().Int64Var(&sessionGPUMem, "gpu-mem", 0, "GPU memory in MiB (required for run mode)")
+	createSessionCmd.Flags().StringVar(&sessionGPUType, "gpu-type", "", "Only use GPUs whose type contains this, e.g. A100 (run mode)")
 	createSessionCmd.Flags().Int64Var(&sessionPinCPU, "pin-cpu", 0, "Pin CPU cores (0 = no pinning)")
 	createSessionCmd.Flags().Int64Var(&sessionPinMem, "pin-mem", 0, "Pin memory in bytes (0 = no pinning)")
 	createSessionCmd.Flags().StringArrayVar(&sessionCmd, "command", nil, "Command to run (for batch execution)")
 	createSessionCmd.Flags().StringArrayVar(&sessionMounts, "mount", nil, "Mount volumes (<node>/<volume>:<path>)")
+	createSessionCmd.Flags().StringArrayVar(&sessionEnv, "env", nil, "Set an environment variable (KEY=VALUE)")
+	createSessionCmd.Flags().StringArrayVar(&sessionEnvFile, "env-file", nil, "Set environment variables from a .env file")
+	createSessionCmd.Flags().StringArrayVar(&sessionSecrets, "secret", nil, "Inject a secret as environment variables (<name>) or files (<name>:<path>)")
+	createSessionCmd.Flags().StringVar(&sessionNode, "node", "", "Node of the volume, or auto to pick one (the argument is then the volume name)")
 }
 
 func runCreateVolume(cmd *cobra.Command, args []string) {
@@ -135,6 +195,39 @@ func runCreateVolume(cmd *cobra.Command, args []string) {
 	nodeName := parts[0]
 	volumeName := parts[1]
 
+	// Read the setup script before connecting to the cluster
+	var setup string
+	switch {
+	case createSetup != "" && createDockerfile != "":
+		exitWithError("--setup and --from-dockerfile cannot be used together", nil)
+	case createSetup != "":
+		if createImage == "" {
+			exitWithError("--setup requires --image (only OS volumes can be set up)", nil)
+		}
+		script, err := os.ReadFile(createSetup)
+		if err != nil {
+			exitWithError("failed to read setup script", err)
+		}
+		setup = string(script)
+	case createDockerfile != "":
+		if createImage != "" {
+			exitWithError("--image cannot be used with --from-dockerfile (the image comes from FROM)", nil)
+		}
+		data, err := os.ReadFile(createDockerfile)
+		if err != nil {
+			exitWithError("failed to read Dockerfile", err)
+		}
+		plan, err := volume.ParseDockerfile(string(data))
+		if err != nil {
+			exitWithError("unsupported Dockerfile", err)
+		}
+		for _, w := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", createDockerfile, w)
+		}
+		createImage = plan.Image
+		setup = plan.Script
+	}
+
 	// Use InterruptibleContext for cleanup on interrupt
 	ctx, cancel := cleanup.InterruptibleContext(context.Background())
 	defer cancel()
@@ -149,6 +242,7 @@ func runCreateVolume(cmd *cobra.Command, args []string) {
 		VolumeName: volumeName,
 		Size:       createSize,
 		Image:      createImage,
+		Setup:      setup,
 	}
 
 	volumeType := "data"
@@ -161,6 +255,9 @@ func runCreateVolume(cmd *cobra.Command, args []string) {
 	if err := volume.Create(ctx, k8sClient, opts); err != nil {
 		exitWithError("", err)
 	}
+	if cleanup.WasInterrupted() {
+		return
+	}
 
 	volumePath := nodeName + "/" + volumeName
 	fmt.Printf("Volume %s created successfully\n", volumePath)
@@ -172,10 +269,22 @@ func runCreateVolume(cmd *cobra.Command, args []string) {
 func runCreateSession(cmd *cobra.Command, args []string) {
 	sessionPath := args[0]
 
-	// Parse path: <node>/<volume>
-	nodeName, volumeName, err := volume.ParseVolumePath(sessionPath)
-	if err != nil {
-		exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+	// Parse path: <node>/<volume>, or <volume> with --node
+	var nodeName, volumeName string
+	if sessionNode != "" {
+		if strings.Contains(sessionPath, "/") {
+			exitWithError("with --node, give only the volume name", nil)
+		}
+		if sessionNode == node.Auto && !sessionRunMode {
+			exitWithError("--node auto is only valid for run mode (use --run flag)", nil)
+		}
+		nodeName, volumeName = sessionNode, sessionPath
+	} else {
+		var err error
+		nodeName, volumeName, err = volume.ParseVolumePath(sessionPath)
+		if err != nil {
+			exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+		}
 	}
 
 	// Parse mounts
@@ -184,6 +293,15 @@ func runCreateSession(cmd *cobra.Command, args []string) {
 		exitWithError("invalid mount format", err)
 	}
 
+	env, err := parseEnv(sessionEnvFile, sessionEnv)
+	if err != nil {
+		exitWithError("invalid environment", err)
+	}
+	secrets, err := parseSecrets(sessionSecrets)
+	if err != nil {
+		exitWithError("invalid secret format", err)
+	}
+
 	ctx := context.Background()
 
 	k8sClient, err := client.New()
@@ -191,10 +309,11 @@ func runCreateSession(cmd *cobra.Command, args []string) {
 		exitWithError("failed to create client", err)
 	}
 
-	// Check for existing session
-	existingMode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, volumeName)
-	if err != nil {
-		exitWithError("", err)
+	// A missing secret would leave the session unable to start
+	for _, s := range secrets {
+		if _, err := secret.Get(ctx, k8sClient, s.Name); err != nil {
+			exitWithError("", err)
+		}
 	}
 
 	requestedMode := volume.SessionModeEdit
@@ -221,6 +340,9 @@ func runCreateSession(cmd *cobra.Command, args []string) {
 		if sessionGPUMem > 0 {
 			exitWithError("--gpu-mem is only valid for run mode (use --run flag)", nil)
 		}
+		if sessionGPUType != "" {
+			exitWithError("--gpu-type is only valid for run mode (use --run flag)", nil)
+		}
 		if sessionPinCPU > 0 {
 			exitWithError("--pin-cpu is only valid for run mode (use --run flag)", nil)
 		}
@@ -232,6 +354,25 @@ func runCreateSession(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if nodeName == node.Auto {
+		nodeName = pickNode(ctx, k8sClient, volumeName)
+	} else if sessionGPUType != "" {
+		// HAMi would leave the session pending forever
+		info, err := node.GetResourceInfo(ctx, k8sClient, nodeName)
+		if err != nil {
+			exitWithError("", err)
+		}
+		if !info.HasGPUType(sessionGPUType) {
+			exitWithError(fmt.Sprintf("node %s has no %s GPUs (has %s)", nodeName, sessionGPUType, strings.Join(info.GPUTypes(), ", ")), nil)
+		}
+	}
+
+	// Check for existing session
+	existingMode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, volumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+
 	if existingMode != "" {
 		if existingMode == requestedMode {
 			// Same mode - deny
@@ -256,18 +397,20 @@ func runCreateSession(cmd *cobra.Command, args []string) {
 
 	if sessionRunMode {
 		// Run mode
-		runGPUSession(ctx, k8sClient, nodeName, volumeName, mounts)
+		runGPUSession(ctx, k8sClient, nodeName, volumeName, mounts, env, secrets)
 	} else {
 		// Edit mode (default)
-		runEditSession(ctx, k8sClient, nodeName, volumeName, mounts)
+		runEditSession(ctx, k8sClient, nodeName, volumeName, mounts, env, secrets)
 	}
 }
 
-func runEditSession(ctx context.Context, k8sClient *client.Client, nodeName, volumeName string, mounts []volume.MountOption) {
+func runEditSession(ctx context.Context, k8sClient *client.Client, nodeName, volumeName string, mounts []volume.MountOption, env map[string]string, secrets []volume.SecretOption) {
 	opts := volume.EditOptions{
 		NodeName:   nodeName,
 		VolumeName: volumeName,
 		Mounts:     mounts,
+		Env:        env,
+		Secrets:    secrets,
 	}
 
 	fmt.Printf("Creating edit session for %s/%s...\n", nodeName, volumeName)
@@ -301,16 +444,19 @@ func runEditSession(ctx context.Context, k8sClient *client.Client, nodeName, vol
 	}
 }
 
-func runGPUSession(ctx context.Context, k8sClient *client.Client, nodeName, volumeName string, mounts []volume.MountOption) {
+func runGPUSession(ctx context.Context, k8sClient *client.Client, nodeName, volumeName string, mounts []volume.MountOption, env map[string]string, secrets []volume.SecretOption) {
 	opts := volume.RunOptions{
 		NodeName:   nodeName,
 		VolumeName: volumeName,
 		GPUs:       sessionGPUNum,
 		GPUMem:     sessionGPUMem,
+		GPUType:    sessionGPUType,
 		Command:    sessionCmd,
 		Mounts:     mounts,
 		PinCPU:     sessionPinCPU,
 		PinMem:     sessionPinMem,
+		Env:        env,
+		Secrets:    secrets,
 	}
 
 	fmt.Printf("Creating run session with %d GPU(s) and %d MiB GPU memory on %s/%s...\n", sessionGPUNum, sessionGPUMem, nodeName, volumeName)
@@ -345,6 +491,35 @@ func runGPUSession(ctx context.Context, k8sClient *client.Client, nodeName, volu
 	}
 }
 
+// pickNode returns the best node holding the OS volume volumeName for a run
+// session with the requested GPUs, or exits explaining why none fits
+func pickNode(ctx context.Context, k8sClient *client.Client, volumeName string) string {
+	gpus := fmt.Sprintf("%d GPU(s)", sessionGPUNum)
+	if sessionGPUType != "" {
+		gpus = fmt.Sprintf("%d %s GPU(s)", sessionGPUNum, sessionGPUType)
+	}
+	fmt.Printf("Finding a node for %s with %d MiB each...\n", gpus, sessionGPUMem)
+	placements, err := findPlacements(ctx, k8sClient, sessionGPUNum, sessionGPUMem, sessionGPUType, volumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	for _, p := range placements {
+		if !p.Fits {
+			break
+		}
+		// A volume can only have one session
+		if mode, err := volume.GetSessionMode(ctx, k8sClient, p.Node, volumeName); err != nil || mode != "" {
+			continue
+		}
+		fmt.Printf("Selected node %s (%s)\n", p.Node, strings.Join(p.Reasons, "; "))
+		return p.Node
+	}
+
+	printPlacements(placements)
+	exitWithError("no node holding "+volumeName+" without a session has room for it", nil)
+	return ""
+}
+
 // parseMounts parses mount options from strings like "node/volume:/path"
 func parseMounts(mountStrs []string) ([]volume.MountOption, error) {
 	var mounts []volume.MountOption
@@ -365,3 +540,41 @@ func parseMounts(mountStrs []string) ([]volume.MountOption, error) {
 	}
 	return mounts, nil
 }
+
+// parseEnv reads environment variables from .env files and KEY=VALUE
+// strings; later values override earlier ones, and --env overrides files
+func parseEnv(files, vars []string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		fileEnv, err := secret.ParseEnvFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		maps.Copy(env, fileEnv)
+	}
+	for _, v := range vars {
+		key, value, found := strings.Cut(v, "=")
+		if !found || !secret.ValidEnvName(key) {
+			return nil, fmt.Errorf("invalid variable '%s', expected KEY=VALUE", v)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// parseSecrets parses secret options from strings like "name" or "name:/path"
+func parseSecrets(secretStrs []string) ([]volume.SecretOption, error) {
+	var secrets []volume.SecretOption
+	for _, s := range secretStrs {
+		name, mountPath, _ := strings.Cut(s, ":")
+		if name == "" || (mountPath != "" && !strings.HasPrefix(mountPath, "/")) {
+			return nil, fmt.Errorf("invalid secret '%s', expected <name> or <name>:<absolute path>", s)
+		}
+		secrets = append(secrets, volume.SecretOption{Name: name, MountPath: mountPath})
+	}
+	return secrets, nil
+}
@@ -3,11 +3,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
 	"github.com/spf13/cobra"
 )
 
+var describeGPUs bool // --gpus flag
+
 var describeCmd = &cobra.Command{
 	Use:     "describe <resource> [name]",
 	Aliases: []string{"des", "desc"},
@@ -27,6 +34,7 @@ Examples:
   sgs des me                      # Show your user info
   sgs des no                      # Describe all nodes
   sgs des node ferrari            # Describe specific node
+  sgs des node ferrari --gpus     # Include each GPU and its vGPUs
   sgs des se                      # Describe all sessions
   sgs des vo                      # Describe all volumes
   sgs des volume ferrari/my-vol   # Describe specific volume
@@ -36,6 +44,10 @@ Examples:
 	Run:  runDescribe,
 }
 
+func init() {
+	describeCmd.Flags().BoolVar(&describeGPUs, "gpus", false, "For nodes, list each GPU with its health and vGPU allocation")
+}
+
 func runDescribe(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 
@@ -50,6 +62,11 @@ func runDescribe(cmd *cobra.Command, args []string) {
 		name = args[1]
 	}
 
+	if isStructuredOutput() || outputFormat == outputName {
+		describeDocument(ctx, k8sClient, resource, name)
+		return
+	}
+
 	switch resource {
 	case "all":
 		getAll(ctx, k8sClient, true)
@@ -83,3 +100,110 @@ func runDescribe(cmd *cobra.Command, args []string) {
 		exitWithError(fmt.Sprintf("unknown resource type: %s", resource), nil)
 	}
 }
+
+// nodeDetail is a node with the volumes and sessions describe shows for it
+type nodeDetail struct {
+	node.ResourceInfo `yaml:",inline"`
+	Volumes           []volume.VolumeInfo   `json:"volumes" yaml:"volumes"`
+	Sessions          []session.SessionInfo `json:"sessions" yaml:"sessions"`
+}
+
+// volumeDetail is a volume with the sessions describe shows for it
+type volumeDetail struct {
+	volume.VolumeInfo `yaml:",inline"`
+	Sessions          []session.SessionInfo `json:"sessions" yaml:"sessions"`
+}
+
+// sessionDetail is a session with its events and what describe makes of them
+type sessionDetail struct {
+	session.SessionInfo `yaml:",inline"`
+	Events              []session.EventInfo `json:"events" yaml:"events"`
+	Diagnosis           []string            `json:"diagnosis" yaml:"diagnosis"`
+}
+
+// describeDocument prints resources as a <Resource>List document or names,
+// with the related resources describe shows. Without a name, all resources
+// of the type are described.
+func describeDocument(ctx context.Context, k8sClient *client.Client, resource, name string) {
+	// Names alone don't need the related resources
+	details := outputFormat != outputName
+
+	switch resource {
+	case "all":
+		getAll(ctx, k8sClient, true)
+	case "nodes", "node", "no":
+		var items []nodeDetail
+		for _, info := range listNodes(ctx, k8sClient, name) {
+			d := nodeDetail{ResourceInfo: info, Volumes: []volume.VolumeInfo{}, Sessions: []session.SessionInfo{}}
+			if details {
+				volumes, err := volume.ListByNode(ctx, k8sClient, info.Name)
+				if err != nil {
+					exitWithError("", err)
+				}
+				sessions, err := session.ListByNode(ctx, k8sClient, info.Name)
+				if err != nil {
+					exitWithError("", err)
+				}
+				d.Volumes = append(d.Volumes, volumes...)
+				d.Sessions = append(d.Sessions, sessions...)
+			}
+			items = append(items, d)
+		}
+		printList("Node", items, func(d nodeDetail) string { return nodeResourceName(d.ResourceInfo) })
+	case "volumes", "volume", "vo", "vol":
+		var items []volumeDetail
+		for _, v := range listVolumes(ctx, k8sClient, name) {
+			d := volumeDetail{VolumeInfo: v, Sessions: []session.SessionInfo{}}
+			if details {
+				sessions, err := session.ListByVolume(ctx, k8sClient, volume.PVCName(v.NodeName, v.VolumeName))
+				if err != nil {
+					exitWithError("", err)
+				}
+				d.Sessions = append(d.Sessions, sessions...)
+			}
+			items = append(items, d)
+		}
+		printList("Volume", items, func(d volumeDetail) string { return volumeResourceName(d.VolumeInfo) })
+	case "sessions", "session", "se":
+		var items []sessionDetail
+		for _, s := range listSessions(ctx, k8sClient, name) {
+			d := sessionDetail{SessionInfo: s, Events: []session.EventInfo{}, Diagnosis: []string{}}
+			if details {
+				events, err := session.Events(ctx, k8sClient, s.PodName)
+				if err != nil {
+					exitWithError("", err)
+				}
+				d.Events = append(d.Events, events...)
+				d.Diagnosis = append(d.Diagnosis, session.Diagnose(s, events)...)
+			}
+			items = append(items, d)
+		}
+		printList("Session", items, func(d sessionDetail) string { return sessionResourceName(d.SessionInfo) })
+	case "workspaces", "workspace", "ws":
+		printList("Workspace", listWorkspaces(ctx, k8sClient, name), workspaceResourceName)
+	case "me":
+		getMe(true)
+	default:
+		exitWithError(fmt.Sprintf("unknown resource type: %s", resource), nil)
+	}
+}
+
+// printGPUDevices prints the per-GPU inventory of a node
+func printGPUDevices(devices []node.GPUDevice) {
+	fmt.Printf("\nGPUs on this node:\n")
+	if len(devices) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  INDEX\tID\tTYPE\tHEALTH\tVGPUS\tMEMORY\tCORES")
+	for _, d := range devices {
+		health := "healthy"
+		if !d.Healthy {
+			health = "unhealthy"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%d/%d\t%d/%dMiB\t%d/%d%%\n",
+			d.Index, d.ID, d.Type, health, d.AllocVGPUs, d.VGPUSlots, d.AllocMemMiB, d.MemoryMiB, d.AllocCores, d.Cores)
+	}
+	w.Flush()
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var duDepth int // --depth flag
+
+var duCmd = &cobra.Command{
+	Use:   "du <node>/<volume>[:<path>]",
+	Short: "Show disk usage of directories in a volume",
+	Long: `Show the disk space used by a directory of a volume and by the
+directories below it, largest first, without starting a session.
+
+Files are read from the volume's session if one is running, or else from a
+short-lived helper pod (see 'sgs ls --help'). Only the volume is measured:
+/proc, /sys and volumes mounted in the session are skipped.
+
+Examples:
+  # Find what fills up a data volume
+  sgs du ferrari/data
+
+  # Look two levels deep into a directory
+  sgs du ferrari/os-vol:/home/user --depth 2`,
+	Args: cobra.ExactArgs(1),
+	Run:  runDu,
+}
+
+func init() {
+	duCmd.Flags().IntVarP(&duDepth, "depth", "d", 1, "Directory levels to show below the path")
+}
+
+func runDu(cmd *cobra.Command, args []string) {
+	if duDepth < 0 {
+		exitWithError("--depth cannot be negative", nil)
+	}
+
+	// Use InterruptibleContext so the helper pod is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	browser, p := openBrowser(ctx, args[0])
+	usage, err := browser.DiskUsage(ctx, p, duDepth)
+	browser.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	if printList("DiskUsage", usage, func(u volume.DiskUsage) string { return u.Path }) {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIZE\tPATH")
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%s\n", volume.FormatBytes(u.Size), u.Path)
+	}
+	w.Flush()
+}
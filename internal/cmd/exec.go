@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <node>/<volume> -- <command> [args...]",
+	Short: "Run a command in an existing session",
+	Long: `Run a one-off, non-interactive command in an existing session.
+
+The command runs inside the session's OS volume without a TTY. Stdout and
+stderr are streamed separately, stdin is forwarded when it is piped, and
+sgs exits with the command's exit code.
+
+Session path format: <node>/<volume>
+
+Examples:
+  # Check GPU status in a run session
+  sgs exec ferrari/os-volume -- nvidia-smi
+
+  # List installed Python packages
+  sgs exec ferrari/os-volume -- pip freeze > requirements.txt
+
+  # Pipe a script into the session
+  sgs exec ferrari/os-volume -- bash -s < setup.sh`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runExec,
+}
+
+func init() {
+	// Treat everything after <node>/<volume> as the remote command,
+	// so "sgs exec ferrari/os ls -la" works without "--"
+	execCmd.Flags().SetInterspersed(false)
+}
+
+func runExec(cmd *cobra.Command, args []string) {
+	sessionPath := args[0]
+	command := args[1:]
+	// Without interspersed flags, cobra keeps the "--" separator in args
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		exitWithError("no command given, expected: sgs exec <node>/<volume> -- <command> [args...]", nil)
+	}
+
+	// Parse path: <node>/<volume>
+	nodeName, volumeName, err := volume.ParseVolumePath(sessionPath)
+	if err != nil {
+		exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Check if session exists
+	mode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, volumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	if mode == "" {
+		exitWithError(fmt.Sprintf("no active session found for %s/%s", nodeName, volumeName), nil)
+	}
+
+	// Convert to pod name: <node>-<volume>
+	podName := volume.SessionPodName(nodeName, volumeName)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := volume.WaitForPodReady(waitCtx, k8sClient, podName, 10*time.Minute); err != nil {
+		exitWithError("failed waiting for pod", err)
+	}
+
+	// Forward stdin only when it is piped or redirected, not from a terminal
+	var stdin io.Reader
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+		stdin = os.Stdin
+	}
+
+	if err := volume.Exec(ctx, k8sClient, podName, command, stdin, os.Stdout, os.Stderr); err != nil {
+		// Propagate the remote exit code (its stderr was already streamed)
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.Exited() {
+			os.Exit(exitErr.ExitStatus())
+		}
+		exitWithError("failed to execute command", err)
+	}
+}
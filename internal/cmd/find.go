@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findName     string // --name flag
+	findType     string // --type flag
+	findMaxDepth int    // --max-depth flag
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find <node>/<volume>[:<path>]",
+	Short: "Find files in a volume",
+	Long: `Find files and directories below a path of a volume, without starting
+a session. Paths are printed one per line.
+
+Files are read from the volume's session if one is running, or else from a
+short-lived helper pod (see 'sgs ls --help'). Only the volume is searched:
+/proc, /sys and volumes mounted in the session are skipped.
+
+Examples:
+  # Find all checkpoints
+  sgs find ferrari/data:/runs --name '*.pt'
+
+  # List the top two levels of directories
+  sgs find ferrari/os-vol:/home/user --type d --max-depth 2`,
+	Args: cobra.ExactArgs(1),
+	Run:  runFind,
+}
+
+func init() {
+	findCmd.Flags().StringVar(&findName, "name", "", "Only show names matching a shell pattern (e.g. '*.pt')")
+	findCmd.Flags().StringVar(&findType, "type", "", "Only show files (f), directories (d) or symlinks (l)")
+	findCmd.Flags().IntVar(&findMaxDepth, "max-depth", 0, "Descend at most this many levels (0 = unlimited)")
+}
+
+func runFind(cmd *cobra.Command, args []string) {
+	switch findType {
+	case "", "f", "d", "l":
+	default:
+		exitWithError(fmt.Sprintf("invalid --type %q (must be f, d or l)", findType), nil)
+	}
+
+	// Use InterruptibleContext so the helper pod is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	browser, p := openBrowser(ctx, args[0])
+	paths, err := browser.Find(ctx, p, volume.FindOptions{Name: findName, Type: findType, MaxDepth: findMaxDepth})
+	browser.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findNodeGPUNum  int    // --gpu-num flag
+	findNodeGPUMem  int64  // --gpu-mem flag (MiB)
+	findNodeGPUType string // --gpu-type flag
+	findNodeVolume  string // --volume flag
+)
+
+var findNodeCmd = &cobra.Command{
+	Use:   "find-node --gpu-num <n> --gpu-mem <MiB>",
+	Short: "Find nodes with room for a run session",
+	Long: `Find the nodes that have room for a run session with the given GPUs, and
+explain why each node does or does not fit.
+
+Nodes are ranked best first: nodes that fit, then by free GPUs and free GPU
+memory. Only nodes your workspace can access fit. GPU memory is per GPU, as
+with 'sgs create session --run'. With --gpu-type, only GPUs whose type
+contains the given value count (e.g. A100). With --volume, only nodes holding a volume
+of that name fit, as sessions run on the node of their volume.
+
+'sgs create session <volume> --node auto' picks the best node this way.
+
+Examples:
+  # Find nodes for 2 GPUs with 20000 MiB each
+  sgs find-node --gpu-num 2 --gpu-mem 20000
+
+  # Only A100 GPUs
+  sgs find-node --gpu-num 1 --gpu-mem 40000 --gpu-type A100
+
+  # Only nodes that hold a copy of the volume train-vol
+  sgs find-node --gpu-num 1 --gpu-mem 8192 --volume train-vol`,
+	Args: cobra.NoArgs,
+	Run:  runFindNode,
+}
+
+func init() {
+	findNodeCmd.Flags().IntVar(&findNodeGPUNum, "gpu-num", 0, "Number of GPUs (required)")
+	findNodeCmd.Flags().Int64Var(&findNodeGPUMem, "gpu-mem", 0, "GPU memory per GPU in MiB (required)")
+	findNodeCmd.Flags().StringVar(&findNodeGPUType, "gpu-type", "", "Only GPUs whose type contains this, e.g. A100")
+	findNodeCmd.Flags().StringVar(&findNodeVolume, "volume", "", "Only nodes holding a volume of this name")
+}
+
+func runFindNode(cmd *cobra.Command, args []string) {
+	if findNodeGPUNum <= 0 {
+		exitWithError("--gpu-num is required", nil)
+	}
+	if findNodeGPUMem <= 0 {
+		exitWithError("--gpu-mem is required", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	placements, err := findPlacements(ctx, k8sClient, findNodeGPUNum, findNodeGPUMem, findNodeGPUType, findNodeVolume)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	if printList("Placement", placements, func(p node.Placement) string { return "node/" + p.Node }) {
+		return
+	}
+	if len(placements) == 0 {
+		fmt.Println("No nodes found")
+		return
+	}
+	printPlacements(placements)
+}
+
+// findPlacements ranks the nodes for a run session. With volumeName, nodes
+// that don't hold an OS volume of that name don't fit.
+func findPlacements(ctx context.Context, k8sClient *client.Client, gpuNum int, gpuMem int64, gpuType, volumeName string) ([]node.Placement, error) {
+	placements, err := node.FindPlacements(ctx, k8sClient, gpuNum, gpuMem, gpuType)
+	if err != nil || volumeName == "" {
+		return placements, err
+	}
+
+	volumes, err := volume.List(ctx, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	holders := make(map[string]bool)
+	for _, v := range volumes {
+		if v.VolumeName == volumeName && v.IsOSVolume {
+			holders[v.NodeName] = true
+		}
+	}
+	if len(holders) == 0 {
+		return nil, fmt.Errorf("no OS volume named %q found on any node", volumeName)
+	}
+
+	for i := range placements {
+		p := &placements[i]
+		if holders[p.Node] {
+			continue
+		}
+		if p.Fits {
+			p.Fits = false
+			p.Reasons = nil
+		}
+		p.Busy = false
+		p.Reasons = append([]string{fmt.Sprintf("no volume %s on this node", volumeName)}, p.Reasons...)
+	}
+	node.Rank(placements)
+	return placements, nil
+}
+
+// printPlacements prints ranked placements as a table
+func printPlacements(placements []node.Placement) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tFITS\tFREE-GPU\tFREE-GPU-MEM\tGPU-TYPE\tREASON")
+	for _, p := range placements {
+		fits := "no"
+		if p.Fits {
+			fits = "yes"
+		}
+		gpuType := p.GPUType
+		if gpuType == "" {
+			gpuType = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%dMiB\t%s\t%s\n",
+			p.Node, fits, p.FreeGPUs, p.FreeGPUMemMiB, gpuType, strings.Join(p.Reasons, "; "))
+	}
+	w.Flush()
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
 	"github.com/bacchus-snu/sgs-cli/internal/client"
 	"github.com/bacchus-snu/sgs-cli/internal/node"
 	"github.com/bacchus-snu/sgs-cli/internal/session"
@@ -17,6 +20,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+var getUsage bool // --usage flag
+
+// volumeUsageWarnPercent is the usage above which get volumes warns that a volume is nearly full
+const volumeUsageWarnPercent = 90
+
 var getCmd = &cobra.Command{
 	Use:   "get <resource> [name]",
 	Short: "Display resources",
@@ -39,13 +47,46 @@ Examples:
   sgs get volume ferrari/my-vol   # Get specific volume info
   sgs get se                      # List all sessions
   sgs get ws                      # List all workspaces
-  sgs get me                      # Show your user info`,
+  sgs get me                      # Show your user info
+
+Output formats (-o, --output):
+  json, yaml    Versioned document (apiVersion: sgs.snucse.org/v1, kind: <Resource>List)
+  wide          Table with additional columns
+  name          One <resource>/<name> per line
+
+  sgs get vo -o json              # List volumes as JSON
+  sgs get se -o name              # List session names for scripting
+
+Volume usage (USED/AVAIL):
+  Shown for volumes mounted by a running session, from the node's volume
+  stats. With --usage, the other volumes are measured with short-lived
+  helper pods (slower). Volumes above 90% full are reported with a warning.
+
+  sgs get vo --usage              # Show usage of all volumes
+
+Watch mode (-w, --watch):
+  Prints a timestamped row whenever a session or volume changes, showing
+  status transitions (e.g. Pending → Running). Press Ctrl+C to stop.
+
+  sgs get se -w                   # Watch all sessions
+  sgs get se ferrari/my-vol -w    # Watch a single session`,
 	Args: cobra.RangeArgs(1, 2),
 	Run:  runGet,
 }
 
+func init() {
+	getCmd.Flags().BoolVarP(&getWatch, "watch", "w", false, "Watch sessions or volumes and print changes as they happen")
+	getCmd.Flags().BoolVar(&getUsage, "usage", false, "Measure the usage of volumes not in use with helper pods")
+}
+
 func runGet(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
+	if getUsage {
+		// Use InterruptibleContext so the helper pods are cleaned up on Ctrl+C
+		var cancel context.CancelFunc
+		ctx, cancel = cleanup.InterruptibleContext(ctx)
+		defer cancel()
+	}
 
 	k8sClient, err := client.New()
 	if err != nil {
@@ -58,31 +99,57 @@ func runGet(cmd *cobra.Command, args []string) {
 		name = args[1]
 	}
 
+	// -o wide shows the extra columns of the verbose table
+	wide := outputFormat == outputWide
+
+	if getUsage {
+		switch resource {
+		case "all", "volumes", "volume", "vo", "vol":
+		default:
+			exitWithError("--usage is only supported for volumes", nil)
+		}
+	}
+
+	if getWatch {
+		if isStructuredOutput() || outputFormat == outputName {
+			exitWithError("--watch only supports table output (default or -o wide)", nil)
+		}
+		switch resource {
+		case "volumes", "volume", "vo", "vol":
+			watchVolumes(ctx, k8sClient, wide, name)
+		case "sessions", "session", "se":
+			watchSessions(ctx, k8sClient, wide, name)
+		default:
+			exitWithError(fmt.Sprintf("--watch is not supported for %s (supported: sessions, volumes)", resource), nil)
+		}
+		return
+	}
+
 	// get always shows table format (even for single items)
 	switch resource {
 	case "all":
-		getAll(ctx, k8sClient, false)
+		getAll(ctx, k8sClient, wide)
 	case "nodes", "node", "no":
-		getNodes(ctx, k8sClient, false, name) // name is filter (empty = all)
+		getNodes(ctx, k8sClient, wide, name) // name is filter (empty = all)
 	case "volumes", "volume", "vo", "vol":
-		getVolumes(ctx, k8sClient, false, name) // name is filter (empty = all)
+		getVolumes(ctx, k8sClient, wide, name) // name is filter (empty = all)
 	case "sessions", "session", "se":
-		getSessions(ctx, k8sClient, false, name) // name is filter (empty = all)
+		getSessions(ctx, k8sClient, wide, name) // name is filter (empty = all)
 	case "workspaces", "workspace", "ws":
-		getWorkspaces(ctx, k8sClient, false, name) // name is filter (empty = all)
+		getWorkspaces(ctx, k8sClient, wide, name) // name is filter (empty = all)
 	case "current-workspace":
-		describeWorkspace(ctx, k8sClient, "", false) // special case: detailed format
+		describeWorkspace(ctx, k8sClient, "", wide) // special case: detailed format
 	case "me":
-		getMe(false)
+		getMe(wide)
 	default:
 		exitWithError(fmt.Sprintf("unknown resource type: %s", resource), nil)
 	}
 }
 
 func getNodes(ctx context.Context, k8sClient *client.Client, verbose bool, filterName string) {
-	nodes, err := node.ListWorkerNodes(ctx, k8sClient)
-	if err != nil {
-		exitWithError("", err)
+	nodes := listNodes(ctx, k8sClient, filterName)
+	if printList("Node", nodes, nodeResourceName) {
+		return
 	}
 
 	if len(nodes) == 0 {
@@ -90,21 +157,6 @@ func getNodes(ctx context.Context, k8sClient *client.Client, verbose bool, filte
 		return
 	}
 
-	// Filter nodes if a specific name is provided
-	if filterName != "" {
-		found := false
-		for _, n := range nodes {
-			if n.Name == filterName {
-				nodes = []corev1.Node{n}
-				found = true
-				break
-			}
-		}
-		if !found {
-			exitWithError(fmt.Sprintf("node %q not found", filterName), nil)
-		}
-	}
-
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	if verbose {
 		fmt.Fprintln(w, "NAME\tACCESS\tSTATUS\tCPU (alloc/cap)\tMEM (alloc/cap)\tGPU (alloc/cap)\tGPU MEM (alloc/cap)")
@@ -112,24 +164,9 @@ func getNodes(ctx context.Context, k8sClient *client.Client, verbose bool, filte
 		fmt.Fprintln(w, "NAME\tACCESS\tSTATUS\tGPU (alloc/cap)\tGPU MEM (alloc/cap)")
 	}
 
-	for _, n := range nodes {
-		status := "Ready"
-		for _, cond := range n.Status.Conditions {
-			if cond.Type == "Ready" && cond.Status != "True" {
-				status = "NotReady"
-				break
-			}
-		}
-
-		// Get node group from label and format access display
-		group := n.Labels["node-restriction.kubernetes.io/nodegroup"]
-		access := formatNodeAccess(group)
-
-		info, err := node.GetResourceInfo(ctx, k8sClient, n.Name)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get resource info for %s: %v\n", n.Name, err)
-			continue
-		}
+	for i := range nodes {
+		info := &nodes[i]
+		access := formatNodeAccess(info.Group)
 
 		// Format metrics strings
 		cpuStr := formatCPUMetrics(info)
@@ -139,11 +176,11 @@ func getNodes(ctx context.Context, k8sClient *client.Client, verbose bool, filte
 
 		if verbose {
 			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				n.Name, access, status,
+				info.Name, access, info.Status,
 				cpuStr, memStr, gpuStr, gpuMemStr)
 		} else {
 			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-				n.Name, access, status,
+				info.Name, access, info.Status,
 				gpuStr, gpuMemStr)
 		}
 	}
@@ -154,6 +191,45 @@ func getNodes(ctx context.Context, k8sClient *client.Client, verbose bool, filte
 	}
 }
 
+// listNodes returns resource info for all worker nodes, or only filterName if set
+func listNodes(ctx context.Context, k8sClient *client.Client, filterName string) []node.ResourceInfo {
+	nodes, err := node.ListWorkerNodes(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	// Filter nodes if a specific name is provided
+	if filterName != "" {
+		found := false
+		for _, n := range nodes {
+			if n.Name == filterName {
+				nodes = []corev1.Node{n}
+				found = true
+				break
+			}
+		}
+		if !found {
+			exitWithError(fmt.Sprintf("node %q not found", filterName), nil)
+		}
+	}
+
+	var infos []node.ResourceInfo
+	for _, n := range nodes {
+		info, err := node.GetResourceInfo(ctx, k8sClient, n.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get resource info for %s: %v\n", n.Name, err)
+			continue
+		}
+		infos = append(infos, *info)
+	}
+	return infos
+}
+
+// nodeResourceName formats a node for --output name
+func nodeResourceName(info node.ResourceInfo) string {
+	return "node/" + info.Name
+}
+
 // formatCPUMetrics formats CPU metrics as "alloc/cap"
 func formatCPUMetrics(info *node.ResourceInfo) string {
 	return fmt.Sprintf("%.1f/%.1f", info.CPUAlloc, info.CPUCapacity)
@@ -204,9 +280,10 @@ func formatWorkspaceAccess(nodeGroup string) string {
 }
 
 func getVolumes(ctx context.Context, k8sClient *client.Client, verbose bool, filterPath string) {
-	volumes, err := volume.List(ctx, k8sClient)
-	if err != nil {
-		exitWithError("", err)
+	volumes := listVolumes(ctx, k8sClient, filterPath)
+	defer warnFullVolumes(volumes)
+	if printList("Volume", volumes, volumeResourceName) {
+		return
 	}
 
 	if len(volumes) == 0 {
@@ -214,6 +291,68 @@ func getVolumes(ctx context.Context, k8sClient *client.Client, verbose bool, fil
 		return
 	}
 
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(volumeHeader(verbose, true), "\t"))
+	for _, v := range volumes {
+		fmt.Fprintln(w, strings.Join(volumeRow(v, verbose, true), "\t"))
+	}
+	w.Flush()
+}
+
+// volumeHeader returns the table columns for volumes, with USED/AVAIL if usage is set
+func volumeHeader(verbose, usage bool) []string {
+	header := []string{"NODE", "NAME", "TYPE", "STATUS", "SIZE"}
+	if usage {
+		header = append(header, "USED", "AVAIL")
+	}
+	if verbose {
+		header = append(header, "IMAGE", "AGE")
+	}
+	return header
+}
+
+// volumeRow returns the table cells for a volume, matching volumeHeader
+func volumeRow(v volume.VolumeInfo, verbose, usage bool) []string {
+	volType := "data"
+	if v.IsOSVolume {
+		volType = "os"
+	}
+	row := []string{v.NodeName, v.VolumeName, volType, v.Status, v.Size}
+	if usage {
+		used, avail := "-", "-"
+		if v.Usage != nil {
+			used = fmt.Sprintf("%s (%d%%)", volume.FormatBytes(v.Usage.UsedBytes), v.Usage.Percent())
+			avail = volume.FormatBytes(v.Usage.AvailableBytes)
+		}
+		row = append(row, used, avail)
+	}
+	if !verbose {
+		return row
+	}
+	image := v.Image
+	if !v.IsOSVolume {
+		image = "-"
+	}
+	return append(row, image, v.Age)
+}
+
+// warnFullVolumes prints a warning to stderr for each volume that is nearly full
+func warnFullVolumes(volumes []volume.VolumeInfo) {
+	for _, v := range volumes {
+		if v.Usage != nil && v.Usage.Percent() >= volumeUsageWarnPercent {
+			fmt.Fprintf(os.Stderr, "Warning: volume %s is %d%% full (%s available); writes fail when it is full (see 'sgs resize volume')\n",
+				volume.FormatVolumePath(v.NodeName, v.VolumeName), v.Usage.Percent(), volume.FormatBytes(v.Usage.AvailableBytes))
+		}
+	}
+}
+
+// listVolumes returns all volumes in the current workspace, or only filterPath (node/volume) if set
+func listVolumes(ctx context.Context, k8sClient *client.Client, filterPath string) []volume.VolumeInfo {
+	volumes, err := volume.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
 	// Filter volumes if a specific path is provided (node/volume format)
 	if filterPath != "" {
 		filterNode, filterName, err := volume.ParseVolumePath(filterPath)
@@ -233,31 +372,17 @@ func getVolumes(ctx context.Context, k8sClient *client.Client, verbose bool, fil
 		}
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	if verbose {
-		fmt.Fprintln(w, "NODE\tNAME\tTYPE\tSTATUS\tSIZE\tIMAGE\tAGE")
-	} else {
-		fmt.Fprintln(w, "NODE\tNAME\tTYPE\tSTATUS\tSIZE")
+	// Names alone don't need the usage. Node stats need no helper pods, so
+	// only the df fallback for volumes not in use waits for --usage.
+	if outputFormat != outputName {
+		volume.AddUsage(ctx, k8sClient, volumes, getUsage)
 	}
+	return volumes
+}
 
-	for _, v := range volumes {
-		volType := "data"
-		if v.IsOSVolume {
-			volType = "os"
-		}
-		if verbose {
-			image := v.Image
-			if !v.IsOSVolume {
-				image = "-"
-			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				v.NodeName, v.VolumeName, volType, v.Status, v.Size, image, v.Age)
-		} else {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-				v.NodeName, v.VolumeName, volType, v.Status, v.Size)
-		}
-	}
-	w.Flush()
+// volumeResourceName formats a volume for --output name
+func volumeResourceName(v volume.VolumeInfo) string {
+	return "volume/" + volume.FormatVolumePath(v.NodeName, v.VolumeName)
 }
 
 func getNodeInfo(ctx context.Context, k8sClient *client.Client, nodeName string, verbose bool) {
@@ -284,6 +409,10 @@ func describeNode(ctx context.Context, k8sClient *client.Client, nodeName string
 		fmt.Printf("  GPU:     (none)\n")
 	}
 
+	if verbose && describeGPUs {
+		printGPUDevices(info.Devices)
+	}
+
 	if verbose {
 		fmt.Printf("\nVolumes on this node:\n")
 		volumes, err := volume.ListByNode(ctx, k8sClient, nodeName)
@@ -319,12 +448,27 @@ func describeNode(ctx context.Context, k8sClient *client.Client, nodeName string
 	}
 }
 
+// userDocument is the JSON/YAML document for "get me"
+type userDocument struct {
+	typeMeta      `yaml:",inline"`
+	user.UserInfo `yaml:",inline"`
+}
+
 func getMe(verbose bool) {
 	u, err := user.GetCurrentUser()
 	if err != nil {
 		exitWithError("failed to get user info", err)
 	}
 
+	switch {
+	case isStructuredOutput():
+		printDocument(userDocument{typeMeta: newTypeMeta("User"), UserInfo: *u})
+		return
+	case outputFormat == outputName:
+		fmt.Println("user/" + u.Username)
+		return
+	}
+
 	fmt.Printf("User:   %s\n", u.Username)
 	fmt.Printf("ID:     %s\n", u.Sub)
 	fmt.Printf("Groups: %s\n", strings.Join(u.Groups, ", "))
@@ -354,7 +498,7 @@ func describeVolume(ctx context.Context, k8sClient *client.Client, volumePath st
 
 	if verbose {
 		fmt.Printf("\nSessions using this volume:\n")
-		sessions, err := session.ListByVolume(ctx, k8sClient, volumeName)
+		sessions, err := session.ListByVolume(ctx, k8sClient, volume.PVCName(nodeName, volumeName))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to list sessions: %v\n", err)
 			return
@@ -369,12 +513,15 @@ func describeVolume(ctx context.Context, k8sClient *client.Client, volumePath st
 	}
 }
 
+// maxDescribeEvents is the number of recent events shown by describe session
+const maxDescribeEvents = 10
+
 func describeSession(ctx context.Context, k8sClient *client.Client, sessionName string, verbose bool) {
 	// Convert node/volume path format to pod name format (node-volume)
 	podName := sessionName
 	if strings.Contains(sessionName, "/") {
 		parts := strings.SplitN(sessionName, "/", 2)
-		podName = parts[0] + "-" + parts[1]
+		podName = volume.SessionPodName(parts[0], parts[1])
 	}
 
 	s, err := session.Get(ctx, k8sClient, podName)
@@ -384,39 +531,79 @@ func describeSession(ctx context.Context, k8sClient *client.Client, sessionName
 
 	// Display with original format (node/volume) for consistency
 	displayName := fmt.Sprintf("%s/%s", s.Node, s.VolumeName)
-	fmt.Printf("Session: %s\n", displayName)
-	fmt.Printf("  Type:   %s\n", s.Type)
-	fmt.Printf("  Volume: %s\n", s.VolumeName)
-	fmt.Printf("  Node:   %s\n", s.Node)
-	fmt.Printf("  Status: %s\n", s.Status)
-	fmt.Printf("  GPUs:   %d\n", s.GPUs)
-	fmt.Printf("  Age:    %s\n", s.Age)
-}
+	status := s.Status
+	if s.Reason != "" {
+		status = fmt.Sprintf("%s (%s)", s.Status, s.Reason)
+	}
 
-func getWorkspaces(ctx context.Context, k8sClient *client.Client, verbose bool, filterName string) {
-	workspaces, err := workspace.List(ctx, k8sClient)
-	if err != nil {
-		exitWithError("", err)
+	fmt.Printf("Session: %s\n", displayName)
+	fmt.Printf("  Type:      %s\n", s.Type)
+	fmt.Printf("  Volume:    %s\n", s.VolumeName)
+	fmt.Printf("  Node:      %s\n", s.Node)
+	fmt.Printf("  Status:    %s\n", status)
+	fmt.Printf("  GPUs:      %d\n", s.GPUs)
+	fmt.Printf("  Age:       %s\n", s.Age)
+	if s.StartedAt != nil {
+		fmt.Printf("  Started:   %s\n", s.StartedAt.Local().Format(time.DateTime))
+	}
+	if s.FinishedAt != nil {
+		fmt.Printf("  Finished:  %s (ran %s)\n", s.FinishedAt.Local().Format(time.DateTime), s.FinishedAt.Sub(*s.StartedAt).Round(time.Second))
+	}
+	if s.ExitCode != nil {
+		fmt.Printf("  Exit Code: %d\n", *s.ExitCode)
 	}
+	fmt.Printf("  Restarts:  %d\n", s.Restarts)
 
-	if len(workspaces) == 0 {
-		fmt.Println("No accessible workspaces found")
+	if !verbose {
 		return
 	}
 
-	// Filter workspaces if a specific name is provided
-	if filterName != "" {
-		found := false
-		for _, ws := range workspaces {
-			if ws.Name == filterName {
-				workspaces = []workspace.WorkspaceInfo{ws}
-				found = true
-				break
+	events, err := session.Events(ctx, k8sClient, s.PodName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list events: %v\n", err)
+	}
+
+	if findings := session.Diagnose(*s, events); len(findings) > 0 {
+		fmt.Printf("\nDiagnosis:\n")
+		for _, finding := range findings {
+			lines := strings.Split(finding, "\n")
+			fmt.Printf("  - %s\n", lines[0])
+			for _, line := range lines[1:] {
+				fmt.Printf("    %s\n", line)
 			}
 		}
-		if !found {
-			exitWithError(fmt.Sprintf("workspace %q not found or access denied", filterName), nil)
+	}
+
+	fmt.Printf("\nEvents:\n")
+	if len(events) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	// Only the most recent events are relevant for diagnosis
+	if len(events) > maxDescribeEvents {
+		events = events[len(events)-maxDescribeEvents:]
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  AGE\tTYPE\tREASON\tMESSAGE")
+	for _, e := range events {
+		age := e.Age
+		if e.Count > 1 {
+			age = fmt.Sprintf("%s (x%d)", age, e.Count)
 		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", age, e.Type, e.Reason, strings.ReplaceAll(e.Message, "\n", " "))
+	}
+	w.Flush()
+}
+
+func getWorkspaces(ctx context.Context, k8sClient *client.Client, verbose bool, filterName string) {
+	workspaces := listWorkspaces(ctx, k8sClient, filterName)
+	if printList("Workspace", workspaces, workspaceResourceName) {
+		return
+	}
+
+	if len(workspaces) == 0 {
+		fmt.Println("No accessible workspaces found")
+		return
 	}
 
 	currentNS := workspace.FromNamespace(k8sClient.Namespace) // Strip ws- prefix for comparison
@@ -444,6 +631,35 @@ func getWorkspaces(ctx context.Context, k8sClient *client.Client, verbose bool,
 	w.Flush()
 }
 
+// listWorkspaces returns all accessible workspaces, or only filterName if set
+func listWorkspaces(ctx context.Context, k8sClient *client.Client, filterName string) []workspace.WorkspaceInfo {
+	workspaces, err := workspace.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	// Filter workspaces if a specific name is provided
+	if filterName != "" {
+		found := false
+		for _, ws := range workspaces {
+			if ws.Name == filterName {
+				workspaces = []workspace.WorkspaceInfo{ws}
+				found = true
+				break
+			}
+		}
+		if !found {
+			exitWithError(fmt.Sprintf("workspace %q not found or access denied", filterName), nil)
+		}
+	}
+	return workspaces
+}
+
+// workspaceResourceName formats a workspace for --output name
+func workspaceResourceName(ws workspace.WorkspaceInfo) string {
+	return "workspace/" + ws.Name
+}
+
 func describeWorkspace(ctx context.Context, k8sClient *client.Client, name string, verbose bool) {
 	var ws *workspace.WorkspaceInfo
 	var err error
@@ -460,6 +676,10 @@ func describeWorkspace(ctx context.Context, k8sClient *client.Client, name strin
 		}
 	}
 
+	if printList("Workspace", []workspace.WorkspaceInfo{*ws}, workspaceResourceName) {
+		return
+	}
+
 	current := ""
 	if ws.Name == workspace.FromNamespace(k8sClient.Namespace) {
 		current = " (current)"
@@ -475,9 +695,9 @@ func describeWorkspace(ctx context.Context, k8sClient *client.Client, name strin
 }
 
 func getSessions(ctx context.Context, k8sClient *client.Client, verbose bool, filterName string) {
-	sessions, err := session.List(ctx, k8sClient)
-	if err != nil {
-		exitWithError("", err)
+	sessions := listSessions(ctx, k8sClient, filterName)
+	if printList("Session", sessions, sessionResourceName) {
+		return
 	}
 
 	if len(sessions) == 0 {
@@ -485,6 +705,43 @@ func getSessions(ctx context.Context, k8sClient *client.Client, verbose bool, fi
 		return
 	}
 
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(sessionHeader(verbose), "\t"))
+	for _, s := range sessions {
+		fmt.Fprintln(w, strings.Join(sessionRow(s, verbose), "\t"))
+	}
+	w.Flush()
+}
+
+// sessionHeader returns the table columns for sessions
+func sessionHeader(verbose bool) []string {
+	if verbose {
+		return []string{"NODE", "VOLUME", "MODE", "STATUS", "GPU", "GPUMEM", "COMMAND", "AGE"}
+	}
+	return []string{"NODE", "VOLUME", "MODE", "STATUS", "COMMAND"}
+}
+
+// sessionRow returns the table cells for a session, matching sessionHeader
+func sessionRow(s session.SessionInfo, verbose bool) []string {
+	cmd := truncateCommand(s.Command, 40)
+	if !verbose {
+		return []string{s.Node, s.VolumeName, string(s.Type), s.Status, cmd}
+	}
+	gpuMem := "-"
+	if s.GPUMem > 0 {
+		gpuMem = fmt.Sprintf("%dMi", s.GPUMem)
+	}
+	return []string{s.Node, s.VolumeName, string(s.Type), s.Status, strconv.Itoa(s.GPUs), gpuMem, cmd, s.Age}
+}
+
+// listSessions returns all sessions in the current workspace, or only filterName
+// (node/volume or pod name) if set
+func listSessions(ctx context.Context, k8sClient *client.Client, filterName string) []session.SessionInfo {
+	sessions, err := session.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
 	// Filter sessions if a specific name is provided (node/volume or pod name format)
 	if filterName != "" {
 		found := false
@@ -501,29 +758,12 @@ func getSessions(ctx context.Context, k8sClient *client.Client, verbose bool, fi
 			exitWithError(fmt.Sprintf("session %q not found in current workspace", filterName), nil)
 		}
 	}
+	return sessions
+}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	if verbose {
-		fmt.Fprintln(w, "NODE\tVOLUME\tMODE\tSTATUS\tGPU\tGPUMEM\tCOMMAND\tAGE")
-	} else {
-		fmt.Fprintln(w, "NODE\tVOLUME\tMODE\tSTATUS\tCOMMAND")
-	}
-
-	for _, s := range sessions {
-		cmd := truncateCommand(s.Command, 40)
-		if verbose {
-			gpuMem := "-"
-			if s.GPUMem > 0 {
-				gpuMem = fmt.Sprintf("%dMi", s.GPUMem)
-			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-				s.Node, s.VolumeName, s.Type, s.Status, s.GPUs, gpuMem, cmd, s.Age)
-		} else {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-				s.Node, s.VolumeName, s.Type, s.Status, cmd)
-		}
-	}
-	w.Flush()
+// sessionResourceName formats a session for --output name
+func sessionResourceName(s session.SessionInfo) string {
+	return "session/" + volume.FormatVolumePath(s.Node, s.VolumeName)
 }
 
 // truncateCommand truncates a command string for display
@@ -616,8 +856,50 @@ func describeWorkspaces(ctx context.Context, k8sClient *client.Client) {
 	}
 }
 
+// allDocument is the JSON/YAML document for "get all"
+type allDocument struct {
+	typeMeta   `yaml:",inline"`
+	Workspaces []workspace.WorkspaceInfo `json:"workspaces" yaml:"workspaces"`
+	Nodes      []node.ResourceInfo       `json:"nodes" yaml:"nodes"`
+	Volumes    []volume.VolumeInfo       `json:"volumes" yaml:"volumes"`
+	Sessions   []session.SessionInfo     `json:"sessions" yaml:"sessions"`
+}
+
 // getAll displays all resources (nodes, volumes, sessions, workspaces)
 func getAll(ctx context.Context, k8sClient *client.Client, verbose bool) {
+	switch {
+	case isStructuredOutput():
+		doc := allDocument{
+			typeMeta:   newTypeMeta("All"),
+			Workspaces: listWorkspaces(ctx, k8sClient, ""),
+			Nodes:      listNodes(ctx, k8sClient, ""),
+			Volumes:    listVolumes(ctx, k8sClient, ""),
+			Sessions:   listSessions(ctx, k8sClient, ""),
+		}
+		// Encode empty sections as [] rather than null
+		if doc.Workspaces == nil {
+			doc.Workspaces = []workspace.WorkspaceInfo{}
+		}
+		if doc.Nodes == nil {
+			doc.Nodes = []node.ResourceInfo{}
+		}
+		if doc.Volumes == nil {
+			doc.Volumes = []volume.VolumeInfo{}
+		}
+		if doc.Sessions == nil {
+			doc.Sessions = []session.SessionInfo{}
+		}
+		printDocument(doc)
+		return
+	case outputFormat == outputName:
+		// Names only, without section headers
+		getWorkspaces(ctx, k8sClient, verbose, "")
+		getNodes(ctx, k8sClient, verbose, "")
+		getVolumes(ctx, k8sClient, verbose, "")
+		getSessions(ctx, k8sClient, verbose, "")
+		return
+	}
+
 	// Get current workspace for header (strip ws- prefix for display)
 	currentWS := workspace.FromNamespace(k8sClient.Namespace)
 
@@ -2,7 +2,8 @@ package cmd
 
 import (
 	"context"
-	"fmt"
+	"os"
+	"time"
 
 	"github.com/bacchus-snu/sgs-cli/internal/client"
 	"github.com/bacchus-snu/sgs-cli/internal/session"
@@ -11,8 +12,11 @@ import (
 )
 
 var (
-	logsFollow bool
-	logsTail   int64
+	logsFollow     bool
+	logsTail       int64
+	logsSince      time.Duration
+	logsTimestamps bool
+	logsPrevious   bool
 )
 
 var logsCmd = &cobra.Command{
@@ -21,6 +25,9 @@ var logsCmd = &cobra.Command{
 	Short:   "Print logs from a session (log)",
 	Long: `Print logs from a session (edit or run pod).
 
+When following, the stream reconnects automatically if the connection drops,
+and sgs exits with the container's exit code once the session terminates.
+
 Session path format: <node>/<volume>
 
 Examples:
@@ -31,7 +38,13 @@ Examples:
   sgs logs ferrari/os-volume -f
 
   # Print last 100 lines
-  sgs logs ferrari/os-volume --tail 100`,
+  sgs logs ferrari/os-volume --tail 100
+
+  # Print logs from the last hour with timestamps
+  sgs logs ferrari/os-volume --since 1h --timestamps
+
+  # Print logs from before the last container restart
+  sgs logs ferrari/os-volume --previous`,
 	Args: cobra.ExactArgs(1),
 	Run:  runLogs,
 }
@@ -39,6 +52,9 @@ Examples:
 func init() {
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow log output")
 	logsCmd.Flags().Int64Var(&logsTail, "tail", -1, "Lines of recent log to show")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show logs newer than a relative duration (e.g. 10m, 1h)")
+	logsCmd.Flags().BoolVar(&logsTimestamps, "timestamps", false, "Prefix each line with its timestamp")
+	logsCmd.Flags().BoolVarP(&logsPrevious, "previous", "p", false, "Show logs of the previous container instance")
 }
 
 func runLogs(cmd *cobra.Command, args []string) {
@@ -51,7 +67,7 @@ func runLogs(cmd *cobra.Command, args []string) {
 	}
 
 	// Convert to pod name: <node>-<volume>
-	podName := fmt.Sprintf("%s-%s", nodeName, volumeName)
+	podName := volume.SessionPodName(nodeName, volumeName)
 
 	ctx := context.Background()
 
@@ -61,14 +77,25 @@ func runLogs(cmd *cobra.Command, args []string) {
 	}
 
 	opts := session.LogsOptions{
-		Follow: logsFollow,
-		Tail:   logsTail,
+		Follow:     logsFollow,
+		Tail:       logsTail,
+		Since:      logsSince,
+		Timestamps: logsTimestamps,
+		Previous:   logsPrevious,
 	}
 
-	logs, err := session.Logs(ctx, k8sClient, podName, opts)
-	if err != nil {
+	if err := session.Logs(ctx, k8sClient, podName, opts, os.Stdout); err != nil {
 		exitWithError("", err)
 	}
 
-	fmt.Print(logs)
+	// Propagate the session's result so scripts can follow a run and check $?
+	if logsFollow && !logsPrevious {
+		exitCode, terminated, err := session.ExitCode(ctx, k8sClient, podName)
+		if err != nil {
+			exitWithError("", err)
+		}
+		if terminated && exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls <node>/<volume>[:<path>]",
+	Short: "List files in a volume",
+	Long: `List the files in a directory of a volume with their sizes and
+modification times, without starting a session.
+
+If the volume has a running session, the files are read from the session;
+its paths are those of the session's filesystem. Otherwise a short-lived
+helper pod mounts the volume read-only; for OS volumes it shows the files
+you changed on top of the image, at the same paths.
+
+Examples:
+  # List the root of a data volume
+  sgs ls ferrari/data
+
+  # List a directory
+  sgs ls ferrari/os-vol:/home/user/results
+
+  # List as JSON
+  sgs ls ferrari/data:/datasets -o json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runLs,
+}
+
+func runLs(cmd *cobra.Command, args []string) {
+	// Use InterruptibleContext so the helper pod is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	browser, p := openBrowser(ctx, args[0])
+	files, err := browser.List(ctx, p)
+	browser.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	if printList("File", files, func(f volume.FileInfo) string { return f.Name }) {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODE\tSIZE\tMODIFIED\tNAME")
+	for _, f := range files {
+		name := f.Name
+		if f.Type == "dir" {
+			name += "/"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Mode, volume.FormatBytes(f.Size), f.ModTime.Format("2006-01-02 15:04"), name)
+	}
+	w.Flush()
+}
+
+// openBrowser parses a <node>/<volume>[:<path>] argument and prepares to
+// browse the volume. It returns the browser, which the caller must close
+// before exiting, and the path in the volume.
+func openBrowser(ctx context.Context, arg string) (*volume.Browser, string) {
+	target, err := volume.ParseCopyPath(arg)
+	if err != nil {
+		exitWithError("invalid path, expected: <node>/<volume>[:<path>]", err)
+	}
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	browser, err := volume.OpenBrowser(ctx, k8sClient, target.NodeName, target.VolumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	return browser, target.Path
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var mvForce bool // --force flag
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <node>/<volume> <node>/<volume>",
+	Short: "Rename a volume or move it to another node",
+	Long: `Rename a volume, or move it to another node.
+
+Volumes can't be renamed in place, so the volume is copied to the new name
+(see 'sgs cp') and the copy is verified with checksums. OS volumes keep their
+image. The source volume is deleted only after the copy succeeded; if the
+move fails or is interrupted before then, the copy is deleted and the source
+is left untouched.
+
+The source volume must have no active session. Snapshots are not moved.
+
+Examples:
+  # Rename a volume
+  sgs mv ferrari/os-vol ferrari/os-old
+
+  # Move a volume to another node, keeping its name
+  sgs mv ferrari/data porsche/data
+
+  # Move without the confirmation prompt
+  sgs mv --force ferrari/data porsche/datasets`,
+	Args: cobra.ExactArgs(2),
+	Run:  runMv,
+}
+
+func init() {
+	mvCmd.Flags().BoolVarP(&mvForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runMv(cmd *cobra.Command, args []string) {
+	// Use InterruptibleContext so the copy is rolled back on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	srcNode, srcVolume, err := volume.ParseVolumePath(args[0])
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid source: %s", args[0]), err)
+	}
+	dstNode, dstVolume, err := volume.ParseVolumePath(args[1])
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid destination: %s", args[1]), err)
+	}
+	srcPath := volume.FormatVolumePath(srcNode, srcVolume)
+	dstPath := volume.FormatVolumePath(dstNode, dstVolume)
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Require confirmation unless --force is set
+	if !mvForce {
+		fmt.Printf("This will move volume '%s' to '%s' and delete '%s'.\n", srcPath, dstPath, srcPath)
+		fmt.Printf("Type the destination volume path to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			exitWithError("failed to read input", err)
+		}
+
+		input = strings.TrimSpace(input)
+		if input != dstPath {
+			fmt.Println("Aborted: confirmation does not match")
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Moving %s to %s...\n", srcPath, dstPath)
+	err = volume.Move(ctx, k8sClient, volume.CopyOptions{
+		SrcNode:   srcNode,
+		SrcVolume: srcVolume,
+		DstNode:   dstNode,
+		DstVolume: dstVolume,
+	})
+	if err != nil {
+		// If context was cancelled (interrupt), signal handler already cleaned up and will exit
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by the global --output/-o flag.
+// An empty format means the default human-readable table.
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputWide = "wide"
+	outputName = "name"
+)
+
+// outputAPIVersion versions the JSON/YAML documents so scripts can detect
+// incompatible changes. Bump it when removing or renaming fields.
+const outputAPIVersion = "sgs.snucse.org/v1"
+
+var outputFormat string // --output/-o flag
+
+// typeMeta identifies the schema of a JSON/YAML document
+type typeMeta struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+}
+
+// listDocument is the top-level document for a list of resources (e.g. VolumeList)
+type listDocument struct {
+	typeMeta `yaml:",inline"`
+	Items    any `json:"items" yaml:"items"`
+}
+
+// validateOutputFormat checks that the --output flag holds a supported format
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", outputJSON, outputYAML, outputWide, outputName:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q (supported: json, yaml, wide, name)", format)
+}
+
+// isStructuredOutput returns true if the output should be a JSON/YAML document
+func isStructuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// newTypeMeta returns the type metadata for a document of the given kind
+func newTypeMeta(kind string) typeMeta {
+	return typeMeta{APIVersion: outputAPIVersion, Kind: kind}
+}
+
+// printDocument writes a document to stdout in the selected structured format
+func printDocument(doc any) {
+	switch outputFormat {
+	case outputJSON:
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			exitWithError("failed to encode output", err)
+		}
+		fmt.Println(string(data))
+	case outputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			exitWithError("failed to encode output", err)
+		}
+		enc.Close()
+	}
+}
+
+// printList writes items as a <kind>List document (json/yaml) or one name per line (name).
+// It returns false if the caller should render the default table instead.
+func printList[T any](kind string, items []T, name func(T) string) bool {
+	switch outputFormat {
+	case outputJSON, outputYAML:
+		if items == nil {
+			items = []T{} // Encode as an empty list, not null
+		}
+		printDocument(listDocument{typeMeta: newTypeMeta(kind + "List"), Items: items})
+		return true
+	case outputName:
+		for _, item := range items {
+			fmt.Println(name(item))
+		}
+		return true
+	}
+	return false
+}
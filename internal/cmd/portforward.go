@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var portForwardCmd = &cobra.Command{
+	Use:     "port-forward <node>/<volume> <[local:]remote> [...]",
+	Aliases: []string{"pf"},
+	Short:   "Forward local ports to a session (pf)",
+	Long: `Forward one or more local ports to a session.
+
+Use this to reach Jupyter, TensorBoard or any web UI running in a session.
+Ports are given as <local>:<remote>, or as <port> to use the same port on
+both sides. The connection is re-established automatically if it drops
+while the session is still running. Press Ctrl+C to stop.
+
+Session path format: <node>/<volume>
+
+Examples:
+  # Forward local port 8888 to Jupyter in the session
+  sgs port-forward ferrari/os-volume 8888
+
+  # Forward local port 16006 to TensorBoard on 6006
+  sgs port-forward ferrari/os-volume 16006:6006
+
+  # Forward multiple ports
+  sgs pf ferrari/os-volume 8888 6006`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runPortForward,
+}
+
+func runPortForward(cmd *cobra.Command, args []string) {
+	sessionPath := args[0]
+	ports := args[1:]
+
+	// Parse path: <node>/<volume>
+	nodeName, volumeName, err := volume.ParseVolumePath(sessionPath)
+	if err != nil {
+		exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+	}
+
+	// Use InterruptibleContext so Ctrl+C closes the local listeners cleanly
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Check if session exists
+	mode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, volumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	if mode == "" {
+		exitWithError(fmt.Sprintf("no active session found for %s/%s", nodeName, volumeName), nil)
+	}
+
+	// Convert to pod name: <node>-<volume>
+	podName := volume.SessionPodName(nodeName, volumeName)
+
+	// Wait for pod to be ready
+	fmt.Println("Waiting for pod to be ready...")
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer waitCancel()
+
+	if err := volume.WaitForPodReady(waitCtx, k8sClient, podName, 10*time.Minute); err != nil {
+		exitWithError("failed waiting for pod", err)
+	}
+
+	if err := volume.PortForward(ctx, k8sClient, podName, ports, os.Stdout, os.Stderr); err != nil {
+		if cleanup.WasInterrupted() {
+			cleanup.WaitForCleanup()
+			return
+		}
+		exitWithError("port forwarding failed", err)
+	}
+}
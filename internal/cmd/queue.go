@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/queue"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queueInterval time.Duration // --interval flag
+	queueOnce     bool          // --once flag
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage the batch job queue",
+	Long: `List, start, cancel and reorder the jobs queued with 'sgs submit'.
+
+The queue is stored in your workspace, so everyone in the workspace shares it.`,
+}
+
+var queueRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start queued jobs as GPUs free up",
+	Long: `Start queued jobs in order as their nodes get room, until the queue is empty.
+
+Every --interval, each queued job starts if its node has room for its GPUs
+(as 'sgs find-node' reports) and its OS volume has no session. Jobs start in
+order per node: a job waiting for GPU memory on a node holds back the jobs
+behind it on that node, while jobs for other nodes can start. At most one job
+starts per node per check, since new sessions take a moment to show up in the
+node's usage.
+
+Jobs that can't start for now, e.g. because the workspace quota is exceeded,
+stay queued and are retried at the next check. Jobs that can never start,
+e.g. because their volume was deleted, stay in the queue as failed, with the
+reason; cancel them with 'sgs queue cancel'. Started jobs leave the queue and are regular
+run sessions: follow them with 'sgs logs' and 'sgs wait'.
+
+Examples:
+  # Start jobs until the queue is empty
+  sgs queue run
+
+  # Start the jobs that fit now, then exit
+  sgs queue run --once`,
+	Args: cobra.NoArgs,
+	Run:  runQueueRun,
+}
+
+var queueListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List queued jobs (ls)",
+	Long: `List the jobs in the queue, next in line first.
+
+Examples:
+  # List queued jobs
+  sgs queue list`,
+	Args: cobra.NoArgs,
+	Run:  runQueueList,
+}
+
+var queueCancelCmd = &cobra.Command{
+	Use:   "cancel <job>...",
+	Short: "Remove jobs from the queue",
+	Long: `Remove jobs from the queue. Jobs that already started are sessions; stop
+them with 'sgs delete session'.
+
+Examples:
+  # Cancel a job
+  sgs queue cancel job-x7k2p`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runQueueCancel,
+}
+
+var queueReorderCmd = &cobra.Command{
+	Use:   "reorder <job> <position>",
+	Short: "Move a job to another position in the queue",
+	Long: `Move a job to a position in the queue; 1 is next in line.
+
+Examples:
+  # Run a job next
+  sgs queue reorder job-x7k2p 1`,
+	Args: cobra.ExactArgs(2),
+	Run:  runQueueReorder,
+}
+
+func init() {
+	queueRunCmd.Flags().DurationVar(&queueInterval, "interval", 30*time.Second, "Time between checks for free GPUs")
+	queueRunCmd.Flags().BoolVar(&queueOnce, "once", false, "Start the jobs that fit now, then exit")
+
+	queueCmd.AddCommand(queueRunCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueCancelCmd)
+	queueCmd.AddCommand(queueReorderCmd)
+}
+
+func runQueueRun(cmd *cobra.Command, args []string) {
+	if queueInterval <= 0 {
+		exitWithError("--interval must be positive", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	lastWaiting := -1
+	for {
+		jobs, err := queue.List(ctx, k8sClient)
+		if err != nil {
+			exitWithError("", err)
+		}
+		var queued []queue.Job
+		for _, j := range jobs {
+			if j.State == queue.StateQueued {
+				queued = append(queued, j)
+			}
+		}
+		if len(queued) == 0 {
+			fmt.Println("No queued jobs")
+			return
+		}
+
+		waiting := len(queued) - startJobs(ctx, k8sClient, queued)
+		if queueOnce {
+			fmt.Printf("%d job(s) still waiting\n", waiting)
+			return
+		}
+		if waiting > 0 && waiting != lastWaiting {
+			fmt.Printf("%d job(s) waiting for GPUs, checking every %s...\n", waiting, queueInterval)
+		}
+		lastWaiting = waiting
+		time.Sleep(queueInterval)
+	}
+}
+
+// startJobs starts the jobs that can start now, in order, and returns how
+// many started. A job waiting for room on a node holds back the jobs behind it
+// on that node, so that smaller jobs can't keep a large one from ever fitting.
+func startJobs(ctx context.Context, k8sClient *client.Client, jobs []queue.Job) int {
+	started := 0
+	busy := make(map[string]bool)    // Nodes that got a job in this pass
+	blocked := make(map[string]bool) // Nodes an earlier job is waiting for
+	for _, job := range jobs {
+		problem, err := jobProblem(ctx, k8sClient, &job.Spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: job %s: %v\n", job.ID, err)
+			continue
+		}
+		if problem != "" {
+			fmt.Fprintf(os.Stderr, "Warning: job %s cannot start: %s\n", job.ID, problem)
+			if err := queue.MarkFailed(ctx, k8sClient, job.ID, problem); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			continue
+		}
+
+		nodeName, waitingFor, err := jobNode(ctx, k8sClient, &job, busy, blocked)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: job %s: %v\n", job.ID, err)
+			continue
+		}
+		for _, n := range waitingFor {
+			blocked[n] = true
+		}
+		if nodeName == "" {
+			continue
+		}
+
+		fmt.Printf("Starting job %s on %s/%s...\n", job.ID, nodeName, job.VolumeName)
+		if _, err := volume.Run(ctx, k8sClient, job.RunOptions(nodeName)); err != nil {
+			// The job itself was checked above, so it may start later
+			fmt.Fprintf(os.Stderr, "Warning: job %s could not start yet: %v\n", job.ID, err)
+			if errors.Is(err, volume.ErrQuotaExceeded) {
+				// The jobs behind it don't fit in the quota either
+				return started
+			}
+			blocked[nodeName] = true
+			continue
+		}
+		busy[nodeName] = true
+		started++
+		if err := queue.Delete(ctx, k8sClient, job.ID); err != nil {
+			// Left in the queue, the job would start again once the session ends
+			exitWithError(fmt.Sprintf("job %s started but could not be removed from the queue", job.ID), err)
+		}
+		fmt.Printf("Job %s started: use 'sgs logs %s/%s' to view output\n", job.ID, nodeName, job.VolumeName)
+	}
+	return started
+}
+
+// jobNode returns the node a job can start on now, or "" if it has to wait.
+// waitingFor lists the nodes the job waits for GPU memory on, skipping nodes
+// that are busy or blocked by earlier jobs.
+func jobNode(ctx context.Context, k8sClient *client.Client, job *queue.Job, busy, blocked map[string]bool) (nodeName string, waitingFor []string, err error) {
+	var candidates []node.Placement
+	if job.NodeName == node.Auto {
+		placements, err := findPlacements(ctx, k8sClient, job.GPUs, job.GPUMem, job.GPUType, job.VolumeName)
+		if err != nil {
+			return "", nil, err
+		}
+		candidates = placements
+	} else {
+		p, err := node.CheckPlacement(ctx, k8sClient, job.NodeName, job.GPUs, job.GPUMem, job.GPUType)
+		if err != nil {
+			return "", nil, err
+		}
+		candidates = []node.Placement{*p}
+	}
+
+	for _, p := range candidates {
+		if busy[p.Node] || blocked[p.Node] {
+			continue
+		}
+		if p.Busy {
+			waitingFor = append(waitingFor, p.Node)
+		}
+		if !p.Fits {
+			continue
+		}
+		// A volume can only have one session
+		mode, err := volume.GetSessionMode(ctx, k8sClient, p.Node, job.VolumeName)
+		if err != nil {
+			return "", nil, err
+		}
+		if mode == "" {
+			return p.Node, nil, nil
+		}
+	}
+	return "", waitingFor, nil
+}
+
+func runQueueList(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	jobs, err := queue.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	if printList("Job", jobs, func(j queue.Job) string { return "job/" + j.ID }) {
+		return
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs queued in current workspace")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POS\tJOB\tSTATE\tVOLUME\tGPU\tGPU-MEM\tCOMMAND\tAGE\tMESSAGE")
+	for _, j := range jobs {
+		gpus := strconv.Itoa(j.GPUs)
+		if j.GPUType != "" {
+			gpus += " " + j.GPUType
+		}
+		message := j.Message
+		if message == "" {
+			message = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s/%s\t%s\t%dMiB\t%s\t%s\t%s\n",
+			j.Position, j.ID, j.State, j.NodeName, j.VolumeName, gpus, j.GPUMem,
+			truncateCommand(strings.Join(j.Command, " "), 40), j.Age, message)
+	}
+	w.Flush()
+}
+
+func runQueueCancel(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	for _, id := range args {
+		if err := queue.Delete(ctx, k8sClient, id); err != nil {
+			exitWithError("", err)
+		}
+		fmt.Printf("Job %s cancelled\n", id)
+	}
+}
+
+func runQueueReorder(cmd *cobra.Command, args []string) {
+	id := args[0]
+	position, err := strconv.Atoi(args[1])
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid position %q", args[1]), nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if err := queue.Reorder(ctx, k8sClient, id, position); err != nil {
+		exitWithError("", err)
+	}
+
+	job, err := queue.Get(ctx, k8sClient, id)
+	if err != nil {
+		exitWithError("", err)
+	}
+	fmt.Printf("Job %s moved to position %d\n", id, job.Position)
+}
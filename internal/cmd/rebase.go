@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+// rebaseListLimit is how many conflicting paths of each kind are shown
+const rebaseListLimit = 20
+
+var (
+	rebaseImage  string // --image flag
+	rebaseBackup bool   // --backup flag
+	rebaseDryRun bool   // --dry-run flag
+	rebaseForce  bool   // --force flag
+)
+
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase",
+	Short: "Change the image of a resource",
+}
+
+var rebaseVolumeCmd = &cobra.Command{
+	Use:     "volume <node>/<volume> --image <image>",
+	Aliases: []string{"volumes", "vo", "vol"},
+	Short:   "Change the image of an OS volume (vo, vol)",
+	Long: `Change the image an OS volume is built on, keeping your changes.
+
+An OS volume stores only the files you changed on top of its image. Rebasing
+puts those changes on top of a new image, e.g. to upgrade CUDA without
+reinstalling your packages. The new image is pulled and checked first; the
+volume is changed only if it starts.
+
+Changes made for the old image may not suit the new one. Before rebasing,
+sgs warns about:
+  - packages you installed with apt, whose package list hides the new image's
+  - Python packages installed for a Python version the new image lacks
+  - files you changed that replace different files of the new image
+  - files you deleted that exist in the new image
+Use --backup to take a snapshot first, so the rebase can be undone with
+'sgs snapshot restore'.
+
+The volume must have no active session.
+
+Examples:
+  # Upgrade the CUDA image of a volume
+  sgs rebase volume ferrari/os-vol --image nvcr.io/nvidia/cuda:12.8.0-base-ubuntu24.04
+
+  # Take a snapshot first
+  sgs rebase volume ferrari/os-vol --image ubuntu:24.04 --backup
+
+  # Only check for conflicts
+  sgs rebase volume ferrari/os-vol --image ubuntu:24.04 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRebaseVolume,
+}
+
+func init() {
+	rebaseVolumeCmd.Flags().StringVar(&rebaseImage, "image", "", "New OS image")
+	rebaseVolumeCmd.Flags().BoolVar(&rebaseBackup, "backup", false, "Take a snapshot of the volume before rebasing")
+	rebaseVolumeCmd.Flags().BoolVar(&rebaseDryRun, "dry-run", false, "Check for conflicts without changing the volume")
+	rebaseVolumeCmd.Flags().BoolVarP(&rebaseForce, "force", "f", false, "Skip confirmation prompt")
+	rebaseCmd.AddCommand(rebaseVolumeCmd)
+}
+
+func runRebaseVolume(cmd *cobra.Command, args []string) {
+	if rebaseImage == "" {
+		exitWithError("--image is required", nil)
+	}
+
+	nodeName, volumeName, err := volume.ParseVolumePath(args[0])
+	if err != nil {
+		exitWithError("invalid volume path", err)
+	}
+	volumePath := volume.FormatVolumePath(nodeName, volumeName)
+
+	// Use InterruptibleContext so helper pods and the old image are restored on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	opts := volume.RebaseOptions{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		Image:      rebaseImage,
+		Backup:     rebaseBackup,
+	}
+	check, err := volume.CheckRebase(ctx, k8sClient, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	printRebaseCheck(check)
+	if rebaseDryRun {
+		fmt.Println("Dry run: volume not changed")
+		return
+	}
+
+	// Require confirmation unless --force is set
+	if !rebaseForce {
+		fmt.Printf("Rebase %s from %s to %s? (y/N): ", volumePath, check.OldImage, check.NewImage)
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	if err := volume.Rebase(ctx, k8sClient, opts); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+	if cleanup.WasInterrupted() {
+		return
+	}
+	fmt.Printf("Volume %s now uses %s\n", volumePath, check.NewImage)
+}
+
+// printRebaseCheck prints the changes that may conflict with the new image
+func printRebaseCheck(check *volume.RebaseCheck) {
+	if !check.HasConflicts() {
+		fmt.Println("No conflicts found with the new image")
+		return
+	}
+
+	fmt.Println("Warning: some of your changes may conflict with the new image:")
+	if check.PackageDB {
+		fmt.Println("  - You installed packages with apt. Your package list replaces the new image's,")
+		fmt.Println("    so apt won't know about the image's packages; reinstall yours after rebasing.")
+	}
+	for _, dir := range check.PythonDirs {
+		fmt.Printf("  - %s holds packages for a Python version the new image doesn't have\n", dir)
+	}
+	printRebasePaths("files you changed replace different files of the new image", check.Shadowed)
+	printRebasePaths("files you deleted exist in the new image and stay deleted", check.Hidden)
+}
+
+// printRebasePaths prints up to rebaseListLimit paths under a heading
+func printRebasePaths(heading string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Printf("  - %d %s:\n", len(paths), heading)
+	for i, p := range paths {
+		if i == rebaseListLimit {
+			fmt.Printf("      ... and %d more\n", len(paths)-rebaseListLimit)
+			break
+		}
+		fmt.Printf("      %s\n", p)
+	}
+}
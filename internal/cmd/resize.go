@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var resizeSize string // --size flag
+
+var resizeCmd = &cobra.Command{
+	Use:   "resize",
+	Short: "Resize a resource",
+}
+
+var resizeVolumeCmd = &cobra.Command{
+	Use:     "volume <node>/<volume> --size <size>",
+	Aliases: []string{"volumes", "vo", "vol"},
+	Short:   "Grow a volume (vo, vol)",
+	Long: `Grow a volume in place, keeping its data.
+
+Volumes can only grow, not shrink. The storage is expanded first, then the
+file system. If a session is using the volume, the file system usually grows
+while it runs; otherwise you are told which sessions to restart. A volume
+without a session grows the next time it is mounted.
+
+Examples:
+  # Grow a volume to 200Gi
+  sgs resize volume ferrari/data-vol --size 200Gi`,
+	Args: cobra.ExactArgs(1),
+	Run:  runResizeVolume,
+}
+
+func init() {
+	resizeVolumeCmd.Flags().StringVar(&resizeSize, "size", "", "New volume size (e.g. 200Gi)")
+	resizeCmd.AddCommand(resizeVolumeCmd)
+}
+
+func runResizeVolume(cmd *cobra.Command, args []string) {
+	volumePath := args[0]
+
+	if resizeSize == "" {
+		exitWithError("--size is required", nil)
+	}
+
+	// Parse node/volume path
+	nodeName, volumeName, err := volume.ParseVolumePath(volumePath)
+	if err != nil {
+		exitWithError("invalid volume path", err)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	fmt.Printf("Resizing volume %s/%s...\n", nodeName, volumeName)
+
+	result, err := volume.Resize(ctx, k8sClient, volume.ResizeOptions{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		Size:       resizeSize,
+	}, os.Stdout)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	switch {
+	case len(result.RestartSessions) > 0:
+		fmt.Printf("Volume %s/%s was expanded to %s, but the file system of a running session can't grow online.\n", nodeName, volumeName, result.NewSize)
+		fmt.Println("Restart these sessions to use the new size:")
+		for _, s := range result.RestartSessions {
+			fmt.Printf("  - %s\n", s)
+		}
+	case result.FileSystemPending:
+		fmt.Printf("Volume %s/%s was expanded to %s; the file system grows the next time a session mounts it\n", nodeName, volumeName, result.NewSize)
+	default:
+		fmt.Printf("Volume %s/%s resized from %s to %s\n", nodeName, volumeName, result.OldSize, result.NewSize)
+	}
+}
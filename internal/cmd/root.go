@@ -40,13 +40,33 @@ Examples:
   sgs fetch                              # Download cluster config
   sgs set workspace <name>               # Set your workspace
   sgs get nodes                          # List available nodes (or: sgs get no)
+  sgs find-node --gpu-num 2 --gpu-mem 20000
+  sgs describe node ferrari --gpus       # Show each GPU and its vGPUs
   sgs get volumes                        # List your volumes (or: sgs get vo)
   sgs create volume ferrari/os --image   # Create OS volume
   sgs create session ferrari/os          # Start edit session
+  sgs resize volume ferrari/data --size 200Gi
+  sgs rebase volume ferrari/os --image ubuntu:24.04 --backup
+  sgs snapshot create ferrari/os         # Snapshot a volume (or: sgs snap)
+  sgs sync ./code ferrari/os:/code       # Send only changed files
+  sgs mv ferrari/data porsche/data       # Move a volume to another node
+  sgs ls ferrari/data:/datasets          # List files (also: cat, du, find)
   sgs create session ferrari/os --run --gpu-num 2 --command "python train.py"
+  sgs submit ferrari/os --gpu-num 2 --gpu-mem 20000 --command "python train.py"
+  sgs queue run                          # Start queued jobs as GPUs free up
+  sgs sweep --volume ferrari/os --grid lr=1e-3,1e-4 --gpu-num 1 --gpu-mem 8192 --command "python train.py --lr {lr}"
+  sgs secret create wandb --from-literal WANDB_API_KEY=...
   sgs attach ferrari/os                  # Attach to session (or: sgs at ferrari/os)
+  sgs exec ferrari/os -- nvidia-smi      # Run a command in a session
+  sgs port-forward ferrari/os 8888       # Forward a port (or: sgs pf ferrari/os 8888)
   sgs logs ferrari/os                    # View logs (or: sgs log ferrari/os)
+  sgs wait ferrari/os --timeout 6h       # Wait for a run to complete
   sgs delete session ferrari/os          # Delete session`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := validateOutputFormat(outputFormat); err != nil {
+			exitWithError("", err)
+		}
+	},
 }
 
 var versionCmd = &cobra.Command{
@@ -67,13 +87,34 @@ func init() {
 	// Disable the default "help" subcommand (use --help flag instead)
 	rootCmd.SetHelpCommand(&cobra.Command{Hidden: true})
 
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format for get/describe: json|yaml|wide|name")
+
 	rootCmd.AddCommand(getCmd)
 	rootCmd.AddCommand(describeCmd)
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(resizeCmd)
+	rootCmd.AddCommand(rebaseCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(secretCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(lsCmd)
+	rootCmd.AddCommand(catCmd)
+	rootCmd.AddCommand(duCmd)
+	rootCmd.AddCommand(findCmd)
+	rootCmd.AddCommand(findNodeCmd)
+	rootCmd.AddCommand(submitCmd)
+	rootCmd.AddCommand(queueCmd)
+	rootCmd.AddCommand(sweepCmd)
 	rootCmd.AddCommand(setCmd)
 	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(portForwardCmd)
+	rootCmd.AddCommand(sshProxyCmd)
+	rootCmd.AddCommand(sshConfigCmd)
+	rootCmd.AddCommand(waitCmd)
 	rootCmd.AddCommand(fetchCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/secret"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretLiterals []string // --from-literal flag
+	secretFiles    []string // --from-file flag
+	secretEnvFiles []string // --from-env-file flag
+	secretReplace  bool     // --replace flag
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets for sessions",
+	Long: `Create, list and delete secrets such as API keys and credentials.
+
+Secrets are stored in your workspace, not on volumes, so they stay out of
+scripts and are not copied along with volumes. Inject them into a session with
+'sgs create session --secret <name>' (as environment variables) or
+'--secret <name>:<path>' (as files). Values are never shown by sgs.`,
+}
+
+var secretCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a secret",
+	Long: `Create a secret with one or more keys.
+
+Keys come from --from-literal KEY=VALUE, --from-file [KEY=]PATH (the key
+defaults to the file name) and --from-env-file (a .env file); the flags can
+be repeated and combined. Keys used as environment variables must be valid
+variable names.
+
+Examples:
+  # Store a WandB API key
+  sgs secret create wandb --from-literal WANDB_API_KEY=abc123
+
+  # Store the keys of a .env file
+  sgs secret create keys --from-env-file .env
+
+  # Store a file, to mount in sessions with --secret hf-token:/root/.cache/huggingface
+  sgs secret create hf-token --from-file token=$HOME/.cache/huggingface/token
+
+  # Replace an existing secret
+  sgs secret create wandb --from-literal WANDB_API_KEY=def456 --replace`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretCreate,
+}
+
+var secretListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List secrets (ls)",
+	Long: `List the secrets in the current workspace with their keys.
+
+Examples:
+  # List secrets
+  sgs secret list`,
+	Args: cobra.NoArgs,
+	Run:  runSecretList,
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"del"},
+	Short:   "Delete a secret (del)",
+	Long: `Delete a secret. Running sessions keep the values they were started with.
+
+Examples:
+  # Delete a secret
+  sgs secret delete wandb`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSecretDelete,
+}
+
+func init() {
+	secretCreateCmd.Flags().StringArrayVar(&secretLiterals, "from-literal", nil, "Key and value (KEY=VALUE)")
+	secretCreateCmd.Flags().StringArrayVar(&secretFiles, "from-file", nil, "File to store ([KEY=]PATH)")
+	secretCreateCmd.Flags().StringArrayVar(&secretEnvFiles, "from-env-file", nil, "Store the variables of a .env file")
+	secretCreateCmd.Flags().BoolVar(&secretReplace, "replace", false, "Overwrite the secret if it exists")
+
+	secretCmd.AddCommand(secretCreateCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretDeleteCmd)
+}
+
+func runSecretCreate(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	data := make(map[string]string)
+	for _, f := range secretEnvFiles {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			exitWithError("failed to read env file", err)
+		}
+		env, err := secret.ParseEnvFile(string(content))
+		if err != nil {
+			exitWithError(fmt.Sprintf("invalid env file %s", f), err)
+		}
+		maps.Copy(data, env)
+	}
+	for _, f := range secretFiles {
+		key, path, found := strings.Cut(f, "=")
+		if !found {
+			key, path = filepath.Base(f), f
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			exitWithError("failed to read file", err)
+		}
+		data[key] = string(content)
+	}
+	for _, l := range secretLiterals {
+		key, value, found := strings.Cut(l, "=")
+		if !found || key == "" {
+			exitWithError(fmt.Sprintf("invalid --from-literal '%s', expected KEY=VALUE", l), nil)
+		}
+		data[key] = value
+	}
+	if len(data) == 0 {
+		exitWithError("no keys given; use --from-literal, --from-file or --from-env-file", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if err := secret.Create(ctx, k8sClient, name, data, secretReplace); err != nil {
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Secret %q created with %d keys\n", name, len(data))
+	fmt.Printf("Use 'sgs create session <node>/<volume> --secret %s' to use it\n", name)
+}
+
+func runSecretList(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	secrets, err := secret.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	if printList("Secret", secrets, func(s secret.SecretInfo) string { return "secret/" + s.Name }) {
+		return
+	}
+
+	if len(secrets) == 0 {
+		fmt.Println("No secrets found in current workspace")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKEYS\tAGE")
+	for _, s := range secrets {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, strings.Join(s.Keys, ","), s.Age)
+	}
+	w.Flush()
+}
+
+func runSecretDelete(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if err := secret.Delete(ctx, k8sClient, name); err != nil {
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Secret %q deleted successfully\n", name)
+}
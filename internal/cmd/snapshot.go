@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotMethod  string // --method flag
+	snapshotRestore string // --to flag
+	snapshotForce   bool   // --force flag
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:     "snapshot",
+	Aliases: []string{"snap"},
+	Short:   "Manage volume snapshots (snap)",
+	Long: `Take, list, delete and restore point-in-time snapshots of volumes.
+
+If the storage class of a volume supports CSI snapshots, snapshots are taken
+by the storage system and are nearly instant. Otherwise, the volume is archived
+with tar into a data volume named <volume>-snap-<snapshot> on the same node;
+this requires the volume to have no active session.
+
+Snapshots of OS volumes keep the OS image, so restored volumes stay bootable.`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <node>/<volume> [name]",
+	Short: "Take a snapshot of a volume",
+	Long: `Take a snapshot of a volume.
+
+The snapshot name defaults to the current time (e.g. 20260102-150405).
+
+Methods (--method):
+  auto  CSI snapshot if the storage class supports it, tar otherwise (default)
+  csi   CSI snapshot only
+  tar   Tar archive in a data volume on the same node
+
+Examples:
+  # Snapshot a volume before an upgrade
+  sgs snapshot create ferrari/os-volume before-upgrade
+
+  # Force a tar snapshot
+  sgs snapshot create ferrari/data-vol --method tar`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runSnapshotCreate,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:     "list [<node>/<volume>]",
+	Aliases: []string{"ls"},
+	Short:   "List snapshots (ls)",
+	Long: `List snapshots of all volumes, or of a single volume.
+
+Examples:
+  # List all snapshots
+  sgs snapshot list
+
+  # List snapshots of a volume
+  sgs snapshot list ferrari/os-volume`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSnapshotList,
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:     "delete <node>/<volume> <name>",
+	Aliases: []string{"del"},
+	Short:   "Delete a snapshot (del)",
+	Long: `Delete a snapshot of a volume.
+
+Examples:
+  # Delete a snapshot
+  sgs snapshot delete ferrari/os-volume before-upgrade`,
+	Args: cobra.ExactArgs(2),
+	Run:  runSnapshotDelete,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <node>/<volume> <name>",
+	Short: "Restore a snapshot",
+	Long: `Restore a snapshot into the volume it was taken from, or into a new volume.
+
+Without --to, the contents of the volume are replaced by the snapshot.
+WARNING: Changes made after the snapshot was taken will be lost!
+
+With --to, the snapshot is restored into a new volume on the same node and the
+source volume is left untouched.
+
+The volume being restored into must have no active session.
+
+Examples:
+  # Roll a volume back to a snapshot
+  sgs snapshot restore ferrari/os-volume before-upgrade
+
+  # Restore into a new volume
+  sgs snapshot restore ferrari/os-volume before-upgrade --to ferrari/os-volume-old`,
+	Args: cobra.ExactArgs(2),
+	Run:  runSnapshotRestore,
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringVar(&snapshotMethod, "method", volume.SnapshotMethodAuto, "Snapshot method: auto|csi|tar")
+	snapshotRestoreCmd.Flags().StringVar(&snapshotRestore, "to", "", "Restore into a new volume (<node>/<volume>) instead of replacing the source")
+	snapshotRestoreCmd.Flags().BoolVarP(&snapshotForce, "force", "f", false, "Skip confirmation prompt")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotDeleteCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) {
+	switch snapshotMethod {
+	case volume.SnapshotMethodAuto, volume.SnapshotMethodCSI, volume.SnapshotMethodTar:
+	default:
+		exitWithError(fmt.Sprintf("invalid --method value %q (supported: auto, csi, tar)", snapshotMethod), nil)
+	}
+
+	// Parse node/volume path
+	nodeName, volumeName, err := volume.ParseVolumePath(args[0])
+	if err != nil {
+		exitWithError("invalid volume path", err)
+	}
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	}
+
+	// Tar snapshots run a pod and create a volume, which are cleaned up on interrupt
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	snapshot, err := volume.CreateSnapshot(ctx, k8sClient, volume.SnapshotOptions{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		Name:       name,
+		Method:     snapshotMethod,
+	})
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Snapshot %q of %s/%s created (%s)\n", snapshot.Name, nodeName, volumeName, snapshot.Method)
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) {
+	filterNode, filterVolume := "", ""
+	if len(args) > 0 {
+		var err error
+		filterNode, filterVolume, err = volume.ParseVolumePath(args[0])
+		if err != nil {
+			exitWithError("invalid volume path", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	all, err := volume.ListSnapshots(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	var snapshots []volume.SnapshotInfo
+	for _, s := range all {
+		if filterNode == "" || (s.NodeName == filterNode && s.VolumeName == filterVolume) {
+			snapshots = append(snapshots, s)
+		}
+	}
+
+	if printList("Snapshot", snapshots, func(s volume.SnapshotInfo) string {
+		return "snapshot/" + volume.FormatVolumePath(s.NodeName, s.VolumeName) + "/" + s.Name
+	}) {
+		return
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots found in current workspace")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VOLUME\tSNAPSHOT\tMETHOD\tSTATUS\tSIZE\tAGE")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			volume.FormatVolumePath(s.NodeName, s.VolumeName), s.Name, s.Method, s.Status, s.Size, s.Age)
+	}
+	w.Flush()
+}
+
+func runSnapshotDelete(cmd *cobra.Command, args []string) {
+	// Parse node/volume path
+	nodeName, volumeName, err := volume.ParseVolumePath(args[0])
+	if err != nil {
+		exitWithError("invalid volume path", err)
+	}
+	name := args[1]
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if err := volume.DeleteSnapshot(ctx, k8sClient, nodeName, volumeName, name); err != nil {
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Snapshot %q of %s/%s deleted successfully\n", name, nodeName, volumeName)
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) {
+	volumePath := args[0]
+	name := args[1]
+
+	// Parse node/volume path
+	nodeName, volumeName, err := volume.ParseVolumePath(volumePath)
+	if err != nil {
+		exitWithError("invalid volume path", err)
+	}
+
+	dstVolume := ""
+	if snapshotRestore != "" {
+		dstNode, dstName, err := volume.ParseVolumePath(snapshotRestore)
+		if err != nil {
+			exitWithError("invalid --to volume path", err)
+		}
+		if dstNode != nodeName {
+			exitWithError(fmt.Sprintf("snapshots can only be restored on the same node (%s); copy the restored volume with 'sgs cp' afterwards", nodeName), nil)
+		}
+		dstVolume = dstName
+	}
+
+	// Require confirmation before replacing the volume unless --force is set
+	if (dstVolume == "" || dstVolume == volumeName) && !snapshotForce {
+		fmt.Printf("WARNING: This will replace the contents of volume '%s/%s' with snapshot %q!\n", nodeName, volumeName, name)
+		fmt.Printf("Type the volume name to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			exitWithError("failed to read input", err)
+		}
+
+		input = strings.TrimSpace(input)
+		if input != volumePath {
+			fmt.Println("Aborted: confirmation does not match")
+			os.Exit(1)
+		}
+	}
+
+	// Restores may run a pod and create a volume, which are cleaned up on interrupt
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	err = volume.RestoreSnapshot(ctx, k8sClient, volume.RestoreOptions{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		Name:       name,
+		DstVolume:  dstVolume,
+	})
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	if dstVolume == "" || dstVolume == volumeName {
+		fmt.Printf("Volume %s/%s restored from snapshot %q\n", nodeName, volumeName, name)
+	} else {
+		fmt.Printf("Snapshot %q restored into %s/%s\n", name, nodeName, dstVolume)
+	}
+}
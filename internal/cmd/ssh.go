@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/bacchus-snu/sgs-cli/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshBootstrap bool   // --bootstrap flag
+	sshPublicKey string // --public-key flag
+	sshUser      string // --user flag (ssh-config)
+	sshPrefix    string // --prefix flag (ssh-config)
+)
+
+var sshProxyCmd = &cobra.Command{
+	Use:   "ssh-proxy <node>/<volume>",
+	Short: "Tunnel SSH to a session (for use as an OpenSSH ProxyCommand)",
+	Long: `Tunnel stdin/stdout to the SSH port of a session.
+
+This command is meant to be used as an OpenSSH ProxyCommand, so that ssh,
+scp, rsync and VS Code Remote-SSH work against sessions. It connects to
+port 22 in the session pod through the cluster API; no ports are exposed.
+
+With --bootstrap, sshd is installed into the OS volume on first use and
+started if it is not running, and your public key is added to root's
+authorized_keys. Use 'sgs ssh-config' to generate the matching Host entries.
+
+Session path format: <node>/<volume>
+
+Examples:
+  # In ~/.ssh/config
+  Host sgs-ferrari-os-volume
+    User root
+    ProxyCommand sgs ssh-proxy ferrari/os-volume --bootstrap
+
+  # Then
+  ssh sgs-ferrari-os-volume`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSSHProxy,
+}
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Print OpenSSH Host entries for your sessions",
+	Long: `Print OpenSSH Host entries for every active session in the current workspace.
+
+Each entry connects through 'sgs ssh-proxy', so it works with ssh, scp and
+VS Code Remote-SSH. The proxy uses the workspace that is current when you
+connect, so regenerate the entries after switching workspaces.
+
+Examples:
+  # Append entries to your SSH config
+  sgs ssh-config --bootstrap >> ~/.ssh/config
+
+  # Connect to a session
+  ssh sgs-ferrari-os-volume`,
+	Args: cobra.NoArgs,
+	Run:  runSSHConfig,
+}
+
+func init() {
+	sshProxyCmd.Flags().BoolVar(&sshBootstrap, "bootstrap", false, "Install and start sshd in the session if needed")
+	sshProxyCmd.Flags().StringVar(&sshPublicKey, "public-key", "", "Public key to authorize with --bootstrap (default: ~/.ssh/id_*.pub)")
+
+	sshConfigCmd.Flags().BoolVar(&sshBootstrap, "bootstrap", false, "Add --bootstrap to the generated ProxyCommand")
+	sshConfigCmd.Flags().StringVar(&sshUser, "user", "root", "SSH user for the generated entries")
+	sshConfigCmd.Flags().StringVar(&sshPrefix, "prefix", "sgs-", "Prefix for the generated Host names")
+}
+
+func runSSHProxy(cmd *cobra.Command, args []string) {
+	sessionPath := args[0]
+
+	// Parse path: <node>/<volume>
+	nodeName, volumeName, err := volume.ParseVolumePath(sessionPath)
+	if err != nil {
+		exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := newProxyClient()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Check if session exists
+	mode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, volumeName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	if mode == "" {
+		exitWithError(fmt.Sprintf("no active session found for %s/%s (start one with 'sgs create session %s/%s')", nodeName, volumeName, nodeName, volumeName), nil)
+	}
+
+	// Convert to pod name: <node>-<volume>
+	podName := volume.SessionPodName(nodeName, volumeName)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := volume.WaitForPodReady(waitCtx, k8sClient, podName, 10*time.Minute); err != nil {
+		exitWithError("failed waiting for pod", err)
+	}
+
+	if sshBootstrap {
+		publicKey, err := readPublicKey(sshPublicKey)
+		if err != nil {
+			exitWithError("", err)
+		}
+		// Stdout carries the SSH stream, so progress goes to stderr
+		if err := volume.BootstrapSSHD(ctx, k8sClient, podName, publicKey, os.Stderr); err != nil {
+			exitWithError("", err)
+		}
+	}
+
+	if err := volume.ProxyPort(ctx, k8sClient, podName, volume.SSHPort, os.Stdin, os.Stdout); err != nil {
+		exitWithError("ssh proxy failed", err)
+	}
+}
+
+// newProxyClient creates a client without touching stdin/stdout, which carry the
+// SSH stream: config refresh messages go to stderr and update prompts read nothing.
+func newProxyClient() (*client.Client, error) {
+	origStdout, origStdin := os.Stdout, os.Stdin
+	os.Stdout = os.Stderr
+	devNull, nullErr := os.Open(os.DevNull)
+	if nullErr == nil {
+		os.Stdin = devNull
+	}
+	defer func() {
+		os.Stdout, os.Stdin = origStdout, origStdin
+		if nullErr == nil {
+			devNull.Close()
+		}
+	}()
+
+	return client.New()
+}
+
+// readPublicKey reads the given public key file, or the first default key in ~/.ssh
+func readPublicKey(path string) (string, error) {
+	candidates := []string{path}
+	if path == "" {
+		sshDir := filepath.Join(os.Getenv("HOME"), ".ssh")
+		candidates = []string{
+			filepath.Join(sshDir, "id_ed25519.pub"),
+			filepath.Join(sshDir, "id_ecdsa.pub"),
+			filepath.Join(sshDir, "id_rsa.pub"),
+		}
+	}
+
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+		if path != "" {
+			return "", fmt.Errorf("failed to read public key: %w", err)
+		}
+	}
+	return "", fmt.Errorf("no SSH public key found in ~/.ssh, use --public-key to specify one")
+}
+
+func runSSHConfig(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	sessions, err := session.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	// Use the absolute path so the entries work from GUI tools like VS Code
+	sgsPath := "sgs"
+	if exe, err := os.Executable(); err == nil {
+		sgsPath = exe
+	}
+	if strings.ContainsAny(sgsPath, " \t") {
+		sgsPath = `"` + sgsPath + `"`
+	}
+
+	proxyFlags := ""
+	if sshBootstrap {
+		proxyFlags = " --bootstrap"
+	}
+
+	fmt.Printf("# Generated by 'sgs ssh-config' (workspace: %s)\n", workspace.FromNamespace(k8sClient.Namespace))
+	count := 0
+	for _, s := range sessions {
+		// Only sessions that can still accept connections
+		if s.Status != "Running" && s.Status != "Pending" {
+			continue
+		}
+		sessionPath := volume.FormatVolumePath(s.Node, s.VolumeName)
+		fmt.Printf("\nHost %s%s-%s\n", sshPrefix, s.Node, s.VolumeName)
+		fmt.Printf("  User %s\n", sshUser)
+		fmt.Printf("  ProxyCommand %s ssh-proxy %s%s\n", sgsPath, sessionPath, proxyFlags)
+		count++
+	}
+
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No active sessions found in current workspace")
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/queue"
+	"github.com/bacchus-snu/sgs-cli/internal/secret"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	submitGPUNum  int      // --gpu-num flag
+	submitGPUMem  int64    // --gpu-mem flag (MiB)
+	submitGPUType string   // --gpu-type flag
+	submitPinCPU  int64    // --pin-cpu flag (cores)
+	submitPinMem  int64    // --pin-mem flag (bytes)
+	submitCommand []string // --command flag
+	submitMounts  []string // --mount flag
+	submitEnv     []string // --env flag (KEY=VALUE)
+	submitEnvFile []string // --env-file flag
+	submitSecrets []string // --secret flag (<name>[:<path>])
+	submitNode    string   // --node flag
+)
+
+var submitCmd = &cobra.Command{
+	Use:   "submit <node>/<volume> --gpu-num <n> --gpu-mem <MiB> --command <cmd>",
+	Short: "Queue a run session until its node has room",
+	Long: `Add a batch job to the workspace queue instead of starting it right away.
+
+A job is a run session, with the same flags as 'sgs create session --run'.
+'sgs queue run' starts queued jobs in order once their node has room for the
+requested GPUs and their OS volume has no session. A job waiting for room on a
+node holds back the jobs behind it on that node, so large jobs are not starved
+by smaller ones; use 'sgs queue reorder' to let a job go first.
+
+With --node auto, the job starts on any node holding a volume of the given
+name that has room (see 'sgs find-node').
+
+Use 'sgs queue list' to see the queue, and 'sgs queue cancel' and
+'sgs queue reorder' to change it.
+
+Examples:
+  # Queue a training run
+  sgs submit ferrari/os-volume --gpu-num 2 --gpu-mem 20000 --command "python train.py"
+
+  # Queue a run on whichever node holding os-volume frees up first
+  sgs submit os-volume --node auto --gpu-num 1 --gpu-mem 8192 --command "python eval.py"
+
+  # Start queued jobs as GPUs free up
+  sgs queue run`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSubmit,
+}
+
+func init() {
+	submitCmd.Flags().IntVar(&submitGPUNum, "gpu-num", 0, "Number of GPUs (required)")
+	submitCmd.Flags().Int64Var(&submitGPUMem, "gpu-mem", 0, "GPU memory in MiB (required)")
+	submitCmd.Flags().StringVar(&submitGPUType, "gpu-type", "", "Only use GPUs whose type contains this, e.g. A100")
+	submitCmd.Flags().Int64Var(&submitPinCPU, "pin-cpu", 0, "Pin CPU cores (0 = no pinning)")
+	submitCmd.Flags().Int64Var(&submitPinMem, "pin-mem", 0, "Pin memory in bytes (0 = no pinning)")
+	submitCmd.Flags().StringArrayVar(&submitCommand, "command", nil, "Command to run (required)")
+	submitCmd.Flags().StringArrayVar(&submitMounts, "mount", nil, "Mount volumes (<node>/<volume>:<path>)")
+	submitCmd.Flags().StringArrayVar(&submitEnv, "env", nil, "Set an environment variable (KEY=VALUE)")
+	submitCmd.Flags().StringArrayVar(&submitEnvFile, "env-file", nil, "Set environment variables from a .env file")
+	submitCmd.Flags().StringArrayVar(&submitSecrets, "secret", nil, "Inject a secret as environment variables (<name>) or files (<name>:<path>)")
+	submitCmd.Flags().StringVar(&submitNode, "node", "", "Node of the volume, or auto to use any node (the argument is then the volume name)")
+}
+
+func runSubmit(cmd *cobra.Command, args []string) {
+	jobPath := args[0]
+
+	// Parse path: <node>/<volume>, or <volume> with --node
+	var nodeName, volumeName string
+	if submitNode != "" {
+		if strings.Contains(jobPath, "/") {
+			exitWithError("with --node, give only the volume name", nil)
+		}
+		nodeName, volumeName = submitNode, jobPath
+	} else {
+		var err error
+		nodeName, volumeName, err = volume.ParseVolumePath(jobPath)
+		if err != nil {
+			exitWithError("invalid job path format, expected: <node>/<volume>", nil)
+		}
+	}
+
+	if submitGPUNum <= 0 {
+		exitWithError("--gpu-num is required", nil)
+	}
+	if submitGPUMem <= 0 {
+		exitWithError("--gpu-mem is required", nil)
+	}
+	if len(submitCommand) == 0 {
+		exitWithError("--command is required (queued jobs run unattended)", nil)
+	}
+
+	mounts, err := parseMounts(submitMounts)
+	if err != nil {
+		exitWithError("invalid mount format", err)
+	}
+	env, err := parseEnv(submitEnvFile, submitEnv)
+	if err != nil {
+		exitWithError("invalid environment", err)
+	}
+	secrets, err := parseSecrets(submitSecrets)
+	if err != nil {
+		exitWithError("invalid secret format", err)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	spec := queue.Spec{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		GPUs:       submitGPUNum,
+		GPUMem:     submitGPUMem,
+		GPUType:    submitGPUType,
+		Command:    submitCommand,
+		Mounts:     mounts,
+		PinCPU:     submitPinCPU,
+		PinMem:     submitPinMem,
+		Env:        env,
+		Secrets:    secrets,
+	}
+
+	// Catch mistakes now rather than when the job is started
+	problem, err := jobProblem(ctx, k8sClient, &spec)
+	if err != nil {
+		exitWithError("", err)
+	}
+	if problem != "" {
+		exitWithError(problem, nil)
+	}
+	for _, s := range secrets {
+		if _, err := secret.Get(ctx, k8sClient, s.Name); err != nil {
+			exitWithError("", err)
+		}
+	}
+
+	job, err := queue.Submit(ctx, k8sClient, spec)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	fmt.Printf("Job %s queued at position %d (%s/%s)\n", job.ID, job.Position, nodeName, volumeName)
+	fmt.Println("Use 'sgs queue run' to start queued jobs as GPUs free up")
+}
+
+// jobProblem returns why a job can never start, e.g. because its volume is
+// gone, or "" if it can start once there is room. With node.Auto, an OS volume
+// of that name must exist on some node. An error means the job could not be
+// checked.
+func jobProblem(ctx context.Context, k8sClient *client.Client, spec *queue.Spec) (string, error) {
+	if spec.NodeName == node.Auto {
+		volumes, err := volume.List(ctx, k8sClient)
+		if err != nil {
+			return "", err
+		}
+		found := false
+		for _, v := range volumes {
+			if v.VolumeName == spec.VolumeName && v.IsOSVolume {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("no OS volume named %q found on any node", spec.VolumeName), nil
+		}
+	} else {
+		osImage, err := volume.GetPVCInfo(ctx, k8sClient, spec.NodeName, spec.VolumeName)
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("volume %s/%s not found", spec.NodeName, spec.VolumeName), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if osImage == "" {
+			return fmt.Sprintf("cannot run: '%s/%s' is not an OS volume (no image configured)", spec.NodeName, spec.VolumeName), nil
+		}
+	}
+
+	if _, err := volume.ValidateMounts(ctx, k8sClient, spec.Mounts); err != nil {
+		if errors.IsNotFound(err) {
+			return err.Error(), nil
+		}
+		return "", err
+	}
+	return "", nil
+}
@@ -0,0 +1,504 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/node"
+	"github.com/bacchus-snu/sgs-cli/internal/secret"
+	"github.com/bacchus-snu/sgs-cli/internal/sweep"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sweepVolume   string        // --volume flag (<node>/<volume>)
+	sweepGrid     []string      // --grid flag (key=v1,v2,...)
+	sweepCommand  string        // --command flag (with {key} placeholders)
+	sweepName     string        // --name flag
+	sweepGPUNum   int           // --gpu-num flag
+	sweepGPUMem   int64         // --gpu-mem flag (MiB)
+	sweepGPUType  string        // --gpu-type flag
+	sweepPinCPU   int64         // --pin-cpu flag (cores)
+	sweepPinMem   int64         // --pin-mem flag (bytes)
+	sweepMounts   []string      // --mount flag
+	sweepEnv      []string      // --env flag (KEY=VALUE)
+	sweepEnvFile  []string      // --env-file flag
+	sweepSecrets  []string      // --secret flag (<name>[:<path>])
+	sweepQueue    bool          // --queue flag
+	sweepDetach   bool          // --detach flag
+	sweepWait     bool          // --wait flag (status)
+	sweepTail     int64         // --tail flag
+	sweepInterval time.Duration // --interval flag
+	sweepForce    bool          // --force flag
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep --volume <node>/<volume> --grid <key>=<v1>,<v2> --command <cmd>",
+	Short: "Run a command over a grid of parameters",
+	Long: `Run a hyperparameter sweep: one run session per combination of the --grid
+values, each on its own clone of the base OS volume.
+
+Each --grid key=v1,v2,... adds a parameter; every combination of values gets
+a run. {key} in --command is replaced with the run's value. Runs use clones
+<name>-1, <name>-2, ... of the base volume on the same node, so they can't
+overwrite each other's outputs. The clones are kept for their results; delete
+them with 'sgs delete volume' when done.
+
+The sweep's runs and results are tracked in a manifest in your workspace.
+sgs waits for all runs to finish, then prints each run's exit code and the
+end of its log, and exits with a non-zero code if any run failed or was not
+started. With --detach, sgs returns once the runs are started; check on them
+later with 'sgs sweep status'. If sgs is interrupted before all runs are
+started, start the rest with 'sgs sweep resume'.
+
+With --queue, runs are submitted to the job queue (see 'sgs submit') instead
+of all started at once; start them with 'sgs queue run'.
+
+Examples:
+  # Sweep learning rate and batch size (4 runs)
+  sgs sweep --volume ferrari/base --grid lr=1e-3,1e-4 --grid bs=32,64 \
+    --gpu-num 1 --gpu-mem 8192 --command "python train.py --lr {lr} --bs {bs}"
+
+  # Queue the runs, and start them as GPUs free up
+  sgs sweep --volume ferrari/base --grid seed=1,2,3 --gpu-num 1 --gpu-mem 8192 \
+    --command "python train.py --seed {seed}" --name seeds --queue --detach
+  sgs queue run
+
+  # Check on a sweep, waiting for it to finish
+  sgs sweep status seeds --wait`,
+	Args: cobra.NoArgs,
+	Run:  runSweep,
+}
+
+var sweepStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show the runs of a sweep and their results",
+	Long: `Update and show the status of a sweep's runs, with the exit code and the
+end of the log of finished runs. sgs exits with a non-zero code if any run
+failed or was not started (see 'sgs sweep resume').
+
+Examples:
+  # Show a sweep
+  sgs sweep status base-1016-1530
+
+  # Wait for the sweep to finish
+  sgs sweep status base-1016-1530 --wait`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSweepStatus,
+}
+
+var sweepResumeCmd = &cobra.Command{
+	Use:   "resume <name>",
+	Short: "Start the runs of a sweep that were not started",
+	Long: `Clone the base volume and start the runs of a sweep that are still pending,
+e.g. because 'sgs sweep' was interrupted or lost its connection. The runs get
+the session options the sweep was created with. Clones that were completely
+made are used as they are. A volume with the name of a clone that is not
+complete, e.g. because sgs was killed while copying, is left alone; delete it
+with 'sgs delete volume' and resume again to start its run.
+
+Like 'sgs sweep', sgs then waits for all runs to finish unless --detach is set.
+
+Examples:
+  # Start the rest of an interrupted sweep
+  sgs sweep resume base-1016-1530`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSweepResume,
+}
+
+var sweepListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List sweeps (ls)",
+	Long: `List the sweeps in the current workspace, newest first.
+
+Examples:
+  # List sweeps
+  sgs sweep list`,
+	Args: cobra.NoArgs,
+	Run:  runSweepList,
+}
+
+func init() {
+	sweepCmd.Flags().StringVar(&sweepVolume, "volume", "", "Base OS volume to clone for each run (<node>/<volume>, required)")
+	sweepCmd.Flags().StringArrayVar(&sweepGrid, "grid", nil, "Parameter and its values (key=v1,v2,...; required, repeatable)")
+	sweepCmd.Flags().StringVar(&sweepCommand, "command", "", "Command to run, with {key} placeholders (required)")
+	sweepCmd.Flags().StringVar(&sweepName, "name", "", "Sweep name and prefix of the cloned volumes (default: <volume>-<MMDD-hhmm>)")
+	sweepCmd.Flags().IntVar(&sweepGPUNum, "gpu-num", 0, "Number of GPUs per run (required)")
+	sweepCmd.Flags().Int64Var(&sweepGPUMem, "gpu-mem", 0, "GPU memory per GPU in MiB (required)")
+	sweepCmd.Flags().StringVar(&sweepGPUType, "gpu-type", "", "Only use GPUs whose type contains this, e.g. A100")
+	sweepCmd.Flags().Int64Var(&sweepPinCPU, "pin-cpu", 0, "Pin CPU cores per run (0 = no pinning)")
+	sweepCmd.Flags().Int64Var(&sweepPinMem, "pin-mem", 0, "Pin memory in bytes per run (0 = no pinning)")
+	sweepCmd.Flags().StringArrayVar(&sweepMounts, "mount", nil, "Mount volumes in every run (<node>/<volume>:<path>)")
+	sweepCmd.Flags().StringArrayVar(&sweepEnv, "env", nil, "Set an environment variable (KEY=VALUE)")
+	sweepCmd.Flags().StringArrayVar(&sweepEnvFile, "env-file", nil, "Set environment variables from a .env file")
+	sweepCmd.Flags().StringArrayVar(&sweepSecrets, "secret", nil, "Inject a secret as environment variables (<name>) or files (<name>:<path>)")
+	sweepCmd.Flags().BoolVar(&sweepQueue, "queue", false, "Submit the runs to the job queue instead of starting them")
+	sweepCmd.Flags().BoolVar(&sweepDetach, "detach", false, "Return once the runs are started instead of waiting for them")
+	sweepCmd.Flags().Int64Var(&sweepTail, "tail", 20, "Lines of log to keep of each run")
+	sweepCmd.Flags().DurationVar(&sweepInterval, "interval", 30*time.Second, "Time between status checks while waiting")
+	sweepCmd.Flags().BoolVarP(&sweepForce, "force", "f", false, "Skip confirmation prompt")
+
+	sweepStatusCmd.Flags().BoolVar(&sweepWait, "wait", false, "Wait for all runs to finish")
+	sweepStatusCmd.Flags().Int64Var(&sweepTail, "tail", 20, "Lines of log to keep of each run")
+	sweepStatusCmd.Flags().DurationVar(&sweepInterval, "interval", 30*time.Second, "Time between status checks while waiting")
+
+	sweepResumeCmd.Flags().BoolVar(&sweepDetach, "detach", false, "Return once the runs are started instead of waiting for them")
+	sweepResumeCmd.Flags().Int64Var(&sweepTail, "tail", 20, "Lines of log to keep of each run")
+	sweepResumeCmd.Flags().DurationVar(&sweepInterval, "interval", 30*time.Second, "Time between status checks while waiting")
+
+	sweepCmd.AddCommand(sweepStatusCmd)
+	sweepCmd.AddCommand(sweepResumeCmd)
+	sweepCmd.AddCommand(sweepListCmd)
+}
+
+func runSweep(cmd *cobra.Command, args []string) {
+	if sweepVolume == "" {
+		exitWithError("--volume is required", nil)
+	}
+	if len(sweepGrid) == 0 {
+		exitWithError("--grid is required", nil)
+	}
+	if sweepCommand == "" {
+		exitWithError("--command is required", nil)
+	}
+	if sweepGPUNum <= 0 {
+		exitWithError("--gpu-num is required", nil)
+	}
+	if sweepGPUMem <= 0 {
+		exitWithError("--gpu-mem is required", nil)
+	}
+	if sweepInterval <= 0 {
+		exitWithError("--interval must be positive", nil)
+	}
+
+	nodeName, baseVolume, err := volume.ParseVolumePath(sweepVolume)
+	if err != nil {
+		exitWithError("invalid --volume format, expected: <node>/<volume>", nil)
+	}
+
+	grid, err := sweep.ParseGrid(sweepGrid)
+	if err != nil {
+		exitWithError("", err)
+	}
+	keys := sweep.Placeholders(sweepCommand)
+	for _, key := range keys {
+		if !slices.ContainsFunc(grid, func(p sweep.Param) bool { return p.Key == key }) {
+			exitWithError(fmt.Sprintf("{%s} in --command is not a --grid parameter", key), nil)
+		}
+	}
+	for _, p := range grid {
+		if !slices.Contains(keys, p.Key) {
+			fmt.Fprintf(os.Stderr, "Warning: --grid %s is not used in --command\n", p.Key)
+		}
+	}
+
+	mounts, err := parseMounts(sweepMounts)
+	if err != nil {
+		exitWithError("invalid mount format", err)
+	}
+	env, err := parseEnv(sweepEnvFile, sweepEnv)
+	if err != nil {
+		exitWithError("invalid environment", err)
+	}
+	secrets, err := parseSecrets(sweepSecrets)
+	if err != nil {
+		exitWithError("invalid secret format", err)
+	}
+
+	name := sweepName
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", baseVolume, time.Now().Format("0102-1504"))
+	}
+	m := sweep.New(name, nodeName, baseVolume, sweepCommand, grid, volume.RunOptions{
+		GPUs:    sweepGPUNum,
+		GPUMem:  sweepGPUMem,
+		GPUType: sweepGPUType,
+		Mounts:  mounts,
+		PinCPU:  sweepPinCPU,
+		PinMem:  sweepPinMem,
+		Env:     env,
+		Secrets: secrets,
+	}, sweepQueue)
+	// The last run has the longest clone name
+	if err := volume.CheckNameLength(nodeName, m.Runs[len(m.Runs)-1].Volume); err != nil {
+		exitWithError(fmt.Sprintf("%v (use --name to choose a shorter sweep name)", err), nil)
+	}
+
+	// Use InterruptibleContext so a clone in progress is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Catch mistakes before cloning anything
+	info, err := volume.Get(ctx, k8sClient, nodeName, baseVolume)
+	if err != nil {
+		exitWithError("", err)
+	}
+	if !info.IsOSVolume {
+		exitWithError(fmt.Sprintf("cannot run: '%s' is not an OS volume (no image configured)", sweepVolume), nil)
+	}
+	if mode, err := volume.GetSessionMode(ctx, k8sClient, nodeName, baseVolume); err != nil {
+		exitWithError("", err)
+	} else if mode != "" {
+		exitWithError(fmt.Sprintf("volume %s has an active session, please delete it first", sweepVolume), nil)
+	}
+	if sweepGPUType != "" {
+		nodeInfo, err := node.GetResourceInfo(ctx, k8sClient, nodeName)
+		if err != nil {
+			exitWithError("", err)
+		}
+		if !nodeInfo.HasGPUType(sweepGPUType) {
+			exitWithError(fmt.Sprintf("node %s has no %s GPUs (has %s)", nodeName, sweepGPUType, strings.Join(nodeInfo.GPUTypes(), ", ")), nil)
+		}
+	}
+	if _, err := volume.ValidateMounts(ctx, k8sClient, mounts); err != nil {
+		exitWithError("", err)
+	}
+	for _, s := range secrets {
+		if _, err := secret.Get(ctx, k8sClient, s.Name); err != nil {
+			exitWithError("", err)
+		}
+	}
+	for _, r := range m.Runs {
+		if _, err := volume.Get(ctx, k8sClient, nodeName, r.Volume); err == nil {
+			exitWithError(fmt.Sprintf("volume %s/%s already exists (use --name to choose another name)", nodeName, r.Volume), nil)
+		}
+	}
+
+	fmt.Printf("Sweep %s: %d runs of %d GPU(s), each on a clone of %s (%s):\n", name, len(m.Runs), sweepGPUNum, sweepVolume, info.Size)
+	for _, r := range m.Runs {
+		fmt.Printf("  %s/%s  %s\n", nodeName, r.Volume, sweep.FormatParams(grid, r.Params))
+	}
+	if !sweepForce {
+		fmt.Print("Clone the volume and start the runs? (y/N): ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	if err := sweep.Create(ctx, k8sClient, m); err != nil {
+		exitWithError("", err)
+	}
+	launchSweep(ctx, k8sClient, m)
+}
+
+func runSweepResume(cmd *cobra.Command, args []string) {
+	if sweepInterval <= 0 {
+		exitWithError("--interval must be positive", nil)
+	}
+
+	// Use InterruptibleContext so a clone in progress is cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	m, err := sweep.Get(ctx, k8sClient, args[0])
+	if err != nil {
+		exitWithError("", err)
+	}
+	pending := m.Counts()[sweep.StatusPending]
+	if pending == 0 {
+		fmt.Printf("Sweep %s has no pending runs (see 'sgs sweep status %s')\n", m.Name, m.Name)
+		return
+	}
+
+	fmt.Printf("Resuming sweep %s: %d of %d runs pending\n", m.Name, pending, len(m.Runs))
+	launchSweep(ctx, k8sClient, m)
+}
+
+// launchSweep starts the pending runs of a sweep, then waits for the runs to
+// finish and reports them unless --detach is set
+func launchSweep(ctx context.Context, k8sClient *client.Client, m *sweep.Manifest) {
+	if err := sweep.Launch(ctx, k8sClient, m); err != nil {
+		// If context was cancelled (interrupt), signal handler already cleaned up and will exit
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError(fmt.Sprintf("failed to launch sweep %s (start the remaining runs with 'sgs sweep resume %s')", m.Name, m.Name), err)
+	}
+	fmt.Println()
+
+	if m.Queue {
+		fmt.Println("Use 'sgs queue run' to start the queued runs")
+	}
+	if sweepDetach {
+		fmt.Printf("Use 'sgs sweep status %s' to check on the sweep\n", m.Name)
+		return
+	}
+	waitSweep(ctx, k8sClient, m)
+	printSweep(m)
+	exitOnSweepFailure(m)
+}
+
+func runSweepStatus(cmd *cobra.Command, args []string) {
+	if sweepInterval <= 0 {
+		exitWithError("--interval must be positive", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	m, err := sweep.Get(ctx, k8sClient, args[0])
+	if err != nil {
+		exitWithError("", err)
+	}
+	if sweepWait {
+		waitSweep(ctx, k8sClient, m)
+	} else if err := sweep.Refresh(ctx, k8sClient, m, sweepTail); err != nil {
+		exitWithError("", err)
+	}
+
+	switch {
+	case isStructuredOutput():
+		printDocument(sweepDocument{typeMeta: newTypeMeta("Sweep"), Manifest: *m})
+	case outputFormat == outputName:
+		fmt.Println("sweep/" + m.Name)
+	default:
+		printSweep(m)
+	}
+	exitOnSweepFailure(m)
+}
+
+func runSweepList(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	sweeps, err := sweep.List(ctx, k8sClient)
+	if err != nil {
+		exitWithError("", err)
+	}
+
+	if printList("Sweep", sweeps, func(m sweep.Manifest) string { return "sweep/" + m.Name }) {
+		return
+	}
+
+	if len(sweeps) == 0 {
+		fmt.Println("No sweeps found in current workspace")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVOLUME\tRUNS\tSTATUS\tCREATED")
+	for _, m := range sweeps {
+		fmt.Fprintf(w, "%s\t%s/%s\t%d\t%s\t%s\n",
+			m.Name, m.NodeName, m.BaseVolume, len(m.Runs), formatSweepCounts(&m), m.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// sweepDocument is the JSON/YAML document for "sweep status"
+type sweepDocument struct {
+	typeMeta       `yaml:",inline"`
+	sweep.Manifest `yaml:",inline"`
+}
+
+// waitSweep refreshes the sweep until none of its runs is active, reporting
+// progress as it changes
+func waitSweep(ctx context.Context, k8sClient *client.Client, m *sweep.Manifest) {
+	last := ""
+	for {
+		if err := sweep.Refresh(ctx, k8sClient, m, sweepTail); err != nil {
+			exitWithError("", err)
+		}
+		if !m.Active() {
+			return
+		}
+		if counts := formatSweepCounts(m); counts != last {
+			fmt.Printf("Waiting for sweep %s: %s\n", m.Name, counts)
+			last = counts
+		}
+		time.Sleep(sweepInterval)
+	}
+}
+
+// printSweep prints the runs of a sweep, then the log tails of finished runs
+func printSweep(m *sweep.Manifest) {
+	fmt.Printf("Sweep:   %s\n", m.Name)
+	fmt.Printf("Volume:  %s/%s\n", m.NodeName, m.BaseVolume)
+	fmt.Printf("Command: %s\n", m.Command)
+	fmt.Printf("Status:  %s\n\n", formatSweepCounts(m))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tVOLUME\tPARAMS\tSTATUS\tEXIT\tMESSAGE")
+	for _, r := range m.Runs {
+		exit := "-"
+		if r.ExitCode != nil {
+			exit = fmt.Sprintf("%d", *r.ExitCode)
+		}
+		message := r.Message
+		if message == "" {
+			message = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s/%s\t%s\t%s\t%s\t%s\n",
+			r.Index, m.NodeName, r.Volume, sweep.FormatParams(m.Grid, r.Params), r.Status, exit, message)
+	}
+	w.Flush()
+
+	for _, r := range m.Runs {
+		if r.ExitCode == nil {
+			continue
+		}
+		fmt.Printf("\n==> Run %d (%s): exit code %d\n", r.Index, sweep.FormatParams(m.Grid, r.Params), *r.ExitCode)
+		if len(r.LogTail) == 0 {
+			fmt.Println("(no output)")
+		}
+		for _, line := range r.LogTail {
+			fmt.Println(line)
+		}
+	}
+}
+
+// formatSweepCounts summarizes the states of a sweep's runs, e.g.
+// "2 running, 1 succeeded"
+func formatSweepCounts(m *sweep.Manifest) string {
+	counts := m.Counts()
+	var parts []string
+	for _, status := range []string{sweep.StatusPending, sweep.StatusQueued, sweep.StatusRunning, sweep.StatusSucceeded, sweep.StatusFailed, sweep.StatusError} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// exitOnSweepFailure exits with code 1 if any finished run did not succeed,
+// or if any run was never started
+func exitOnSweepFailure(m *sweep.Manifest) {
+	counts := m.Counts()
+	if pending := counts[sweep.StatusPending]; pending > 0 {
+		fmt.Fprintf(os.Stderr, "%d run(s) not started yet; start them with 'sgs sweep resume %s'\n", pending, m.Name)
+	}
+	if counts[sweep.StatusFailed] > 0 || counts[sweep.StatusError] > 0 || counts[sweep.StatusPending] > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncDelete   bool     // --delete flag
+	syncExclude  []string // --exclude flag
+	syncDryRun   bool     // --dry-run flag
+	syncChecksum bool     // --checksum flag
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <source> <destination>",
+	Short: "Sync a directory between volumes or with your machine",
+	Long: `Make the destination directory match the source directory, sending only
+new and changed files.
+
+Sources and destinations are directories, either in a volume or on your machine:
+  <node>/<volume>            Root of a volume
+  <node>/<volume>:<path>     Directory in a volume
+  <local-path>               Local directory (absolute, or starting with ./, ../ or ~/)
+
+The contents of the source directory are synced into the destination
+directory, which is created if needed. Files are compared by size and
+modification time, or by content with --checksum. If a sync is interrupted,
+run the same command again: files that were already sent are skipped.
+
+Exclude patterns (--exclude, repeatable) without "/" match a file or directory
+name at any depth (e.g. '*.tmp', '__pycache__'); patterns with "/" match the
+path from the synced directory (e.g. 'checkpoints/*.pt'). Excluded files are
+neither sent nor deleted.
+
+Both volumes must have no active session.
+
+Examples:
+  # Sync a dataset between nodes
+  sgs sync ferrari/data:/datasets porsche/data:/datasets
+
+  # Upload code changes, removing files deleted locally
+  sgs sync ./project ferrari/os-vol:/home/user/project --delete --exclude .git
+
+  # See what would be downloaded without copying anything
+  sgs sync ferrari/os-vol:/home/user/results ./results --dry-run`,
+	Args: cobra.ExactArgs(2),
+	Run:  runSync,
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncDelete, "delete", false, "Delete destination files that are not in the source")
+	syncCmd.Flags().StringArrayVar(&syncExclude, "exclude", nil, "Skip files matching a pattern (repeatable)")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Show what would change without changing anything")
+	syncCmd.Flags().BoolVarP(&syncChecksum, "checksum", "c", false, "Compare file contents instead of modification times (slower)")
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	// Use InterruptibleContext so the copy pods are cleaned up on Ctrl+C
+	ctx, cancel := cleanup.InterruptibleContext(context.Background())
+	defer cancel()
+
+	src, err := volume.ParseSyncPath(args[0])
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid source: %s", args[0]), err)
+	}
+	dst, err := volume.ParseSyncPath(args[1])
+	if err != nil {
+		exitWithError(fmt.Sprintf("invalid destination: %s", args[1]), err)
+	}
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	if syncDryRun {
+		fmt.Printf("Comparing %s to %s (dry run)...\n", src, dst)
+	} else {
+		fmt.Printf("Syncing %s to %s...\n", src, dst)
+	}
+
+	result, err := volume.Sync(ctx, k8sClient, volume.SyncOptions{
+		Src:      src,
+		Dst:      dst,
+		Delete:   syncDelete,
+		Exclude:  syncExclude,
+		DryRun:   syncDryRun,
+		Checksum: syncChecksum,
+	})
+	if err != nil {
+		// If context was cancelled (interrupt), signal handler already cleaned up and will exit
+		if ctx.Err() != nil {
+			return
+		}
+		exitWithError("", err)
+	}
+
+	if syncDryRun {
+		for _, p := range result.Transferred {
+			fmt.Printf("  send    %s\n", p)
+		}
+		for _, p := range result.Deleted {
+			fmt.Printf("  delete  %s\n", p)
+		}
+		fmt.Printf("Would send %d files (%s), delete %d, leave %d unchanged\n",
+			len(result.Transferred), volume.FormatBytes(result.Bytes), len(result.Deleted), result.Unchanged)
+		return
+	}
+
+	fmt.Printf("Sent %d files (%s), deleted %d, %d unchanged\n",
+		len(result.Transferred), volume.FormatBytes(result.Bytes), len(result.Deleted), result.Unchanged)
+}
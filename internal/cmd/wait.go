@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"github.com/spf13/cobra"
+)
+
+var (
+	waitFor     string        // --for flag
+	waitTimeout time.Duration // --timeout flag
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait <node>/<volume>",
+	Short: "Wait for a session to reach a state",
+	Long: `Block until a session reaches the requested state.
+
+States (--for):
+  completed   The session has terminated. sgs exits with the container's
+              exit code, so a failed run makes the pipeline fail.
+  running     The session pod is running.
+  ready       The session container is running and ready.
+
+sgs exits with a non-zero code if the timeout elapses, or if the session
+can no longer reach the state (e.g. it failed, or its image can't be pulled).
+
+Session path format: <node>/<volume>
+
+Examples:
+  # Submit a run and wait for its result
+  sgs create session ferrari/os-volume --command "python train.py"
+  sgs wait ferrari/os-volume --for=completed --timeout 6h
+
+  # Wait until a session is ready to accept connections
+  sgs wait ferrari/os-volume --for=ready`,
+	Args: cobra.ExactArgs(1),
+	Run:  runWait,
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitFor, "for", "completed", "State to wait for: completed|running|ready")
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 0, "Give up after this duration (e.g. 30m, 6h; 0 = wait forever)")
+}
+
+func runWait(cmd *cobra.Command, args []string) {
+	sessionPath := args[0]
+
+	var condition volume.PodCondition
+	switch waitFor {
+	case "completed":
+		condition = volume.PodCompleted
+	case "running":
+		condition = volume.PodRunning
+	case "ready":
+		condition = volume.PodReady
+	default:
+		exitWithError(fmt.Sprintf("invalid --for value %q (supported: completed, running, ready)", waitFor), nil)
+	}
+
+	// Parse path: <node>/<volume>
+	nodeName, volumeName, err := volume.ParseVolumePath(sessionPath)
+	if err != nil {
+		exitWithError("invalid session path format, expected: <node>/<volume>", nil)
+	}
+
+	ctx := context.Background()
+
+	k8sClient, err := client.New()
+	if err != nil {
+		exitWithError("failed to create client", err)
+	}
+
+	// Convert to pod name: <node>-<volume>
+	podName := volume.SessionPodName(nodeName, volumeName)
+
+	// Check if session exists. Finished run sessions count: waiting for
+	// them to complete reports their exit code right away.
+	if _, err := session.Get(ctx, k8sClient, podName); err != nil {
+		exitWithError("", err)
+	}
+
+	if _, err := volume.WaitForPod(ctx, k8sClient, podName, waitTimeout, condition); err != nil {
+		if errors.Is(err, volume.ErrWaitTimeout) {
+			exitWithError(fmt.Sprintf("timed out after %s waiting for %s to be %s", waitTimeout, sessionPath, waitFor), nil)
+		}
+		exitWithError(fmt.Sprintf("session %s will not become %s", sessionPath, waitFor), err)
+	}
+
+	if waitFor != "completed" {
+		fmt.Printf("Session %s is %s\n", sessionPath, waitFor)
+		return
+	}
+
+	exitCode, _, err := session.ExitCode(ctx, k8sClient, podName)
+	if err != nil {
+		exitWithError("", err)
+	}
+	fmt.Printf("Session %s completed (exit code %d)\n", sessionPath, exitCode)
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var getWatch bool // --watch flag
+
+// watchTable prints a row each time a resource changes. Columns are padded to
+// fixed widths because rows are printed as they arrive and can't be realigned.
+type watchTable struct {
+	widths    []int
+	statusCol int                 // Index of the STATUS column in the rows
+	rows      map[string][]string // Last printed row per resource
+}
+
+// newWatchTable prints the header and returns a table for rows matching it
+func newWatchTable(header []string) *watchTable {
+	t := &watchTable{rows: make(map[string][]string)}
+	header = append([]string{"TIME"}, header...)
+	for i, col := range header {
+		t.widths = append(t.widths, max(len(col), 12)+2)
+		if col == "STATUS" {
+			t.statusCol = i - 1
+		}
+	}
+	t.printRow(header)
+	return t
+}
+
+// printRow prints cells padded to the column widths, widening columns as needed
+func (t *watchTable) printRow(cells []string) {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i == len(cells)-1 {
+			b.WriteString(cell)
+			break
+		}
+		if width := utf8.RuneCountInString(cell) + 2; width > t.widths[i] {
+			t.widths[i] = width
+		}
+		fmt.Fprintf(&b, "%-*s", t.widths[i], cell)
+	}
+	fmt.Println(strings.TrimRight(b.String(), " "))
+}
+
+// update prints the row for a resource if it changed since it was last printed.
+// Status changes are shown as transitions, e.g. "Pending → Running".
+func (t *watchTable) update(eventType watch.EventType, key string, cells []string) {
+	prev, seen := t.rows[key]
+	if eventType != watch.Deleted && seen && slices.Equal(prev, cells) {
+		return
+	}
+
+	display := slices.Clone(cells)
+	if eventType == watch.Deleted {
+		display[t.statusCol] = "Deleted"
+	}
+	if seen && prev[t.statusCol] != display[t.statusCol] {
+		display[t.statusCol] = prev[t.statusCol] + " → " + display[t.statusCol]
+	}
+	t.printRow(append([]string{time.Now().Format("15:04:05")}, display...))
+
+	if eventType == watch.Deleted {
+		delete(t.rows, key)
+	} else {
+		t.rows[key] = cells
+	}
+}
+
+// watchSessions prints session changes until interrupted, optionally only for filterName
+func watchSessions(ctx context.Context, k8sClient *client.Client, verbose bool, filterName string) {
+	t := newWatchTable(sessionHeader(verbose))
+	err := session.Watch(ctx, k8sClient, func(eventType watch.EventType, s session.SessionInfo) {
+		sessionPath := volume.FormatVolumePath(s.Node, s.VolumeName)
+		if filterName != "" && s.PodName != filterName && sessionPath != filterName {
+			return
+		}
+		t.update(eventType, s.PodName, sessionRow(s, verbose))
+	})
+	if err != nil {
+		exitWithError("", err)
+	}
+}
+
+// watchVolumes prints volume changes until interrupted, optionally only for filterPath (node/volume)
+func watchVolumes(ctx context.Context, k8sClient *client.Client, verbose bool, filterPath string) {
+	if filterPath != "" {
+		if _, _, err := volume.ParseVolumePath(filterPath); err != nil {
+			exitWithError("invalid volume path", err)
+		}
+	}
+
+	t := newWatchTable(volumeHeader(verbose, false))
+	err := volume.Watch(ctx, k8sClient, func(eventType watch.EventType, v volume.VolumeInfo) {
+		volumePath := volume.FormatVolumePath(v.NodeName, v.VolumeName)
+		if filterPath != "" && volumePath != filterPath {
+			return
+		}
+		t.update(eventType, volumePath, volumeRow(v, verbose, false))
+	})
+	if err != nil {
+		exitWithError("", err)
+	}
+}
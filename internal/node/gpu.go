@@ -0,0 +1,130 @@
+package node
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// HamiAllocatedAnnotationKey is the HAMi pod annotation listing the GPUs assigned to its containers
+const HamiAllocatedAnnotationKey = "hami.io/vgpu-devices-allocated"
+
+// GPUDevice is a physical GPU of a node as registered with HAMi, with the
+// vGPUs currently allocated on it
+type GPUDevice struct {
+	Index     int    `json:"index" yaml:"index"`
+	ID        string `json:"id" yaml:"id"`
+	Type      string `json:"type" yaml:"type"`
+	MemoryMiB int64  `json:"memoryMiB" yaml:"memoryMiB"`
+	Cores     int    `json:"cores" yaml:"cores"`         // Compute share, 100 = the whole GPU
+	VGPUSlots int    `json:"vgpuSlots" yaml:"vgpuSlots"` // Maximum number of vGPUs on this GPU
+	Healthy   bool   `json:"healthy" yaml:"healthy"`
+
+	AllocVGPUs  int   `json:"allocVGPUs" yaml:"allocVGPUs"`
+	AllocMemMiB int64 `json:"allocMemMiB" yaml:"allocMemMiB"`
+	AllocCores  int   `json:"allocCores" yaml:"allocCores"`
+}
+
+// FreeMemMiB returns the GPU memory not allocated to any vGPU
+func (d *GPUDevice) FreeMemMiB() int64 {
+	return max(d.MemoryMiB-d.AllocMemMiB, 0)
+}
+
+// MatchesType reports whether the GPU matches a type selector the way HAMi
+// matches nvidia.com/use-gputype: by case-insensitive substring
+func (d *GPUDevice) MatchesType(gpuType string) bool {
+	return strings.Contains(strings.ToUpper(d.Type), strings.ToUpper(gpuType))
+}
+
+// GPUTypes returns the distinct GPU types of a node, in device order
+func (info *ResourceInfo) GPUTypes() []string {
+	var types []string
+	for _, d := range info.Devices {
+		if d.Type != "" && !slices.Contains(types, d.Type) {
+			types = append(types, d.Type)
+		}
+	}
+	return types
+}
+
+// HasGPUType reports whether a node has at least one GPU matching gpuType
+func (info *ResourceInfo) HasGPUType(gpuType string) bool {
+	return slices.ContainsFunc(info.Devices, func(d GPUDevice) bool { return d.MatchesType(gpuType) })
+}
+
+// hamiDevices returns the GPUs registered in a node's HAMi annotation
+func hamiDevices(node *corev1.Node) []HamiGPUInfo {
+	annotation := node.Annotations[HamiAnnotationKey]
+	if annotation == "" {
+		return nil
+	}
+	var gpus []HamiGPUInfo
+	if err := json.Unmarshal([]byte(annotation), &gpus); err != nil {
+		return nil
+	}
+	return gpus
+}
+
+// gpuDevices returns the GPUs of a node with the vGPUs that HAMi allocated
+// to pods on them
+func gpuDevices(node *corev1.Node, pods []corev1.Pod) []GPUDevice {
+	gpus := hamiDevices(node)
+	if len(gpus) == 0 {
+		return nil
+	}
+
+	devices := make([]GPUDevice, len(gpus))
+	byID := make(map[string]*GPUDevice, len(gpus))
+	for i, gpu := range gpus {
+		devices[i] = GPUDevice{
+			Index:     i,
+			ID:        gpu.ID,
+			Type:      gpu.Type,
+			MemoryMiB: gpu.DevMem,
+			Cores:     gpu.DevCore,
+			VGPUSlots: gpu.Count,
+			Healthy:   gpu.Health,
+		}
+		byID[gpu.ID] = &devices[i]
+	}
+
+	for _, pod := range pods {
+		for _, alloc := range parseHamiAllocation(pod.Annotations[HamiAllocatedAnnotationKey]) {
+			if d, ok := byID[alloc.id]; ok {
+				d.AllocVGPUs++
+				d.AllocMemMiB += alloc.memMiB
+				d.AllocCores += alloc.cores
+			}
+		}
+	}
+	return devices
+}
+
+// hamiAllocation is a vGPU assigned to a container
+type hamiAllocation struct {
+	id     string
+	memMiB int64
+	cores  int
+}
+
+// parseHamiAllocation parses the HAMi allocation annotation of a pod. It
+// lists containers separated by ";", each with its vGPUs separated by ":",
+// each vGPU as "<GPU ID>,<vendor>,<memory MiB>,<cores>".
+func parseHamiAllocation(annotation string) []hamiAllocation {
+	var allocations []hamiAllocation
+	for _, container := range strings.Split(annotation, ";") {
+		for _, device := range strings.Split(container, ":") {
+			fields := strings.Split(device, ",")
+			if len(fields) < 4 || fields[0] == "" {
+				continue
+			}
+			mem, _ := strconv.ParseInt(fields[2], 10, 64)
+			cores, _ := strconv.Atoi(fields[3])
+			allocations = append(allocations, hamiAllocation{id: fields[0], memMiB: mem, cores: cores})
+		}
+	}
+	return allocations
+}
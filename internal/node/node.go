@@ -5,7 +5,6 @@ package node
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/bacchus-snu/sgs-cli/internal/client"
@@ -29,25 +28,31 @@ type HamiGPUInfo struct {
 
 // ResourceInfo holds resource usage information for a node
 type ResourceInfo struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"` // "Ready" or "NotReady"
+
 	// CPU metrics (in cores)
-	CPUAlloc    float64 // sum of pod limits
-	CPUCapacity float64 // node allocatable
+	CPUAlloc    float64 `json:"cpuAlloc" yaml:"cpuAlloc"`       // sum of pod limits
+	CPUCapacity float64 `json:"cpuCapacity" yaml:"cpuCapacity"` // node allocatable
 
 	// Host Memory metrics (in GiB)
-	MemAlloc    float64 // sum of pod limits
-	MemCapacity float64 // node allocatable
+	MemAlloc    float64 `json:"memAllocGiB" yaml:"memAllocGiB"`       // sum of pod limits
+	MemCapacity float64 `json:"memCapacityGiB" yaml:"memCapacityGiB"` // node allocatable
 
 	// GPU metrics
-	GPUAlloc    int64  // allocated vGPU count (sum of pod limits)
-	GPUCapacity int64  // physical GPU count
-	GPUType     string // GPU type (e.g., "NVIDIA GeForce GTX 1080")
+	GPUAlloc    int64  `json:"gpuAlloc" yaml:"gpuAlloc"`                   // allocated vGPU count (sum of pod limits)
+	GPUCapacity int64  `json:"gpuCapacity" yaml:"gpuCapacity"`             // physical GPU count
+	GPUType     string `json:"gpuType,omitempty" yaml:"gpuType,omitempty"` // GPU type (e.g., "NVIDIA GeForce GTX 1080")
 
 	// GPU Memory metrics (in GiB)
-	GPUMemAlloc    float64 // sum of pod limits (in GiB)
-	GPUMemCapacity float64 // physical total (in GiB)
+	GPUMemAlloc    float64 `json:"gpuMemAllocGiB" yaml:"gpuMemAllocGiB"`       // sum of pod limits (in GiB)
+	GPUMemCapacity float64 `json:"gpuMemCapacityGiB" yaml:"gpuMemCapacityGiB"` // physical total (in GiB)
 
 	// Node group
-	Group string // node group from node-restriction.kubernetes.io/nodegroup label
+	Group string `json:"group" yaml:"group"` // node group from node-restriction.kubernetes.io/nodegroup label
+
+	// Per-GPU inventory and vGPU allocation
+	Devices []GPUDevice `json:"gpus,omitempty" yaml:"gpus,omitempty"`
 }
 
 // ListWorkerNodes returns all worker nodes (excludes control plane nodes)
@@ -140,6 +145,9 @@ func GetResourceInfo(ctx context.Context, c *client.Client, nodeName string) (*R
 	}
 
 	return &ResourceInfo{
+		Name:   node.Name,
+		Status: Status(node),
+
 		CPUAlloc:    cpuToFloat(cpuAlloc),
 		CPUCapacity: cpuCapacity,
 
@@ -153,9 +161,21 @@ func GetResourceInfo(ctx context.Context, c *client.Client, nodeName string) (*R
 		GPUMemCapacity: gpuMemCapacity,
 
 		Group: group,
+
+		Devices: gpuDevices(node, pods.Items),
 	}, nil
 }
 
+// Status returns "Ready" or "NotReady" based on the node's Ready condition
+func Status(node *corev1.Node) string {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady && cond.Status != corev1.ConditionTrue {
+			return "NotReady"
+		}
+	}
+	return "Ready"
+}
+
 // cpuToFloat converts a CPU quantity to float64 cores
 func cpuToFloat(q *resource.Quantity) float64 {
 	return float64(q.MilliValue()) / 1000.0
@@ -169,16 +189,7 @@ func memoryToGiB(q *resource.Quantity) float64 {
 
 // parseHamiAnnotation parses the HAMi GPU annotation and returns GPU count, type, and total memory
 func parseHamiAnnotation(node *corev1.Node) (gpuCount int64, gpuType string, gpuMemMiB int64) {
-	annotation := node.Annotations[HamiAnnotationKey]
-	if annotation == "" {
-		return 0, "", 0
-	}
-
-	var gpus []HamiGPUInfo
-	if err := json.Unmarshal([]byte(annotation), &gpus); err != nil {
-		return 0, "", 0
-	}
-
+	gpus := hamiDevices(node)
 	gpuCount = int64(len(gpus))
 	for _, gpu := range gpus {
 		if gpuType == "" && gpu.Type != "" {
@@ -0,0 +1,159 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/workspace"
+)
+
+// Auto is the node name that asks sgs to pick a node (e.g. --node auto)
+const Auto = "auto"
+
+// Placement tells whether a run session with the requested GPUs fits on a
+// node, and why or why not
+type Placement struct {
+	Node          string   `json:"node" yaml:"node"`
+	Fits          bool     `json:"fits" yaml:"fits"`
+	Reasons       []string `json:"reasons" yaml:"reasons"`
+	FreeGPUs      int64    `json:"freeGPUs" yaml:"freeGPUs"`           // GPUs not allocated to any session
+	FreeGPUMemMiB int64    `json:"freeGPUMemMiB" yaml:"freeGPUMemMiB"` // GPU memory not allocated to any session
+	GPUType       string   `json:"gpuType,omitempty" yaml:"gpuType,omitempty"`
+	Busy          bool     `json:"-" yaml:"-"` // Doesn't fit only for lack of free GPU memory, so it will once sessions end
+}
+
+// FindPlacements checks every worker node for a run session with gpuNum GPUs
+// of gpuMemMiB memory each (HAMi limits apply per GPU). With gpuType, only
+// GPUs whose type contains it count, as with HAMi's type selector. Nodes the
+// current workspace can't access never fit. The result is ranked by Rank.
+func FindPlacements(ctx context.Context, c *client.Client, gpuNum int, gpuMemMiB int64, gpuType string) ([]Placement, error) {
+	ws, err := workspace.GetCurrent(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace info: %w", err)
+	}
+	nodes, err := ListWorkerNodes(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var placements []Placement
+	for _, n := range nodes {
+		info, err := GetResourceInfo(ctx, c, n.Name)
+		if err != nil {
+			placements = append(placements, Placement{Node: n.Name, Reasons: []string{"resource usage unavailable"}})
+			continue
+		}
+		group := n.Labels["node-restriction.kubernetes.io/nodegroup"]
+		placements = append(placements, place(info, workspace.CanAccessNode(ws.NodeGroup, group), gpuNum, gpuMemMiB, gpuType))
+	}
+	Rank(placements)
+	return placements, nil
+}
+
+// CheckPlacement checks a single node like FindPlacements
+func CheckPlacement(ctx context.Context, c *client.Client, nodeName string, gpuNum int, gpuMemMiB int64, gpuType string) (*Placement, error) {
+	ws, err := workspace.GetCurrent(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace info: %w", err)
+	}
+	info, err := GetResourceInfo(ctx, c, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	p := place(info, workspace.CanAccessNode(ws.NodeGroup, info.Group), gpuNum, gpuMemMiB, gpuType)
+	return &p, nil
+}
+
+// place checks whether gpuNum GPUs of gpuMemMiB each (of gpuType, if set)
+// fit on a node
+func place(info *ResourceInfo, accessible bool, gpuNum int, gpuMemMiB int64, gpuType string) Placement {
+	capacity := info.GPUCapacity
+	capacityMiB := int64(info.GPUMemCapacity * 1024)
+	p := Placement{
+		Node:          info.Name,
+		FreeGPUs:      max(info.GPUCapacity-info.GPUAlloc, 0),
+		FreeGPUMemMiB: max(capacityMiB-int64(info.GPUMemAlloc*1024), 0),
+		GPUType:       info.GPUType,
+	}
+	if gpuType != "" {
+		// Only the GPUs HAMi may assign count
+		capacity, capacityMiB, p.FreeGPUs, p.FreeGPUMemMiB, p.GPUType = 0, 0, 0, 0, ""
+		for _, d := range info.Devices {
+			if !d.MatchesType(gpuType) {
+				continue
+			}
+			capacity++
+			capacityMiB += d.MemoryMiB
+			p.FreeGPUMemMiB += d.FreeMemMiB()
+			if d.AllocVGPUs == 0 {
+				p.FreeGPUs++
+			}
+			if p.GPUType == "" {
+				p.GPUType = d.Type
+			}
+		}
+	}
+
+	if info.Status != "Ready" {
+		p.Reasons = append(p.Reasons, "node is not ready")
+	}
+	if !accessible {
+		p.Reasons = append(p.Reasons, fmt.Sprintf("not accessible from this workspace (node group %s)", info.Group))
+	}
+	switch {
+	case info.GPUCapacity == 0:
+		p.Reasons = append(p.Reasons, "no GPUs")
+	case capacity == 0:
+		p.Reasons = append(p.Reasons, fmt.Sprintf("no %s GPUs (has %s)", gpuType, strings.Join(info.GPUTypes(), ", ")))
+	case capacity < int64(gpuNum):
+		p.Reasons = append(p.Reasons, fmt.Sprintf("has %d%s GPUs, need %d", capacity, typeSuffix(gpuType), gpuNum))
+	default:
+		// HAMi places each requested GPU on a different physical GPU
+		if perGPU := capacityMiB / capacity; perGPU < gpuMemMiB {
+			p.Reasons = append(p.Reasons, fmt.Sprintf("GPUs have %d MiB, need %d MiB each", perGPU, gpuMemMiB))
+		}
+		if need := int64(gpuNum) * gpuMemMiB; p.FreeGPUMemMiB < need {
+			p.Reasons = append(p.Reasons, fmt.Sprintf("%d MiB GPU memory free, need %d MiB", p.FreeGPUMemMiB, need))
+			p.Busy = len(p.Reasons) == 1
+		}
+	}
+
+	if len(p.Reasons) == 0 {
+		p.Fits = true
+		p.Reasons = []string{fmt.Sprintf("%d of %d%s GPUs and %d MiB GPU memory free", p.FreeGPUs, capacity, typeSuffix(gpuType), p.FreeGPUMemMiB)}
+		if p.FreeGPUs < int64(gpuNum) {
+			// Memory fits, so the GPUs can be shared with other sessions
+			p.Reasons[0] += "; GPUs will be shared with other sessions"
+		}
+	}
+	return p
+}
+
+// typeSuffix returns " <gpuType>" for reasons, or "" without a type
+func typeSuffix(gpuType string) string {
+	if gpuType == "" {
+		return ""
+	}
+	return " " + gpuType
+}
+
+// Rank sorts placements best first: nodes that fit before those that don't,
+// then by free GPUs and free GPU memory, then by name
+func Rank(placements []Placement) {
+	sort.SliceStable(placements, func(i, j int) bool {
+		a, b := placements[i], placements[j]
+		if a.Fits != b.Fits {
+			return a.Fits
+		}
+		if a.FreeGPUs != b.FreeGPUs {
+			return a.FreeGPUs > b.FreeGPUs
+		}
+		if a.FreeGPUMemMiB != b.FreeGPUMemMiB {
+			return a.FreeGPUMemMiB > b.FreeGPUMemMiB
+		}
+		return a.Node < b.Node
+	})
+}
@@ -0,0 +1,263 @@
+// Package queue manages the batch job queue of a workspace. Each job is a
+// ConfigMap holding a run session to start once its node has room for it;
+// 'sgs queue run' starts them.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Job states
+const (
+	StateQueued = "queued" // Waiting for room on its node
+	StateFailed = "failed" // Could not be started; see Message
+)
+
+// jobKey is the ConfigMap key holding the job's Spec as JSON
+const jobKey = "job"
+
+// Spec is the run session a job starts
+type Spec struct {
+	NodeName   string                `json:"node" yaml:"node"` // node.Auto to pick a node when starting
+	VolumeName string                `json:"volume" yaml:"volume"`
+	GPUs       int                   `json:"gpus" yaml:"gpus"`
+	GPUMem     int64                 `json:"gpuMemMiB" yaml:"gpuMemMiB"`
+	GPUType    string                `json:"gpuType,omitempty" yaml:"gpuType,omitempty"`
+	Command    []string              `json:"command" yaml:"command"`
+	Mounts     []volume.MountOption  `json:"mounts,omitempty" yaml:"mounts,omitempty"`
+	PinCPU     int64                 `json:"pinCPU,omitempty" yaml:"pinCPU,omitempty"`
+	PinMem     int64                 `json:"pinMem,omitempty" yaml:"pinMem,omitempty"`
+	Env        map[string]string     `json:"env,omitempty" yaml:"env,omitempty"`
+	Secrets    []volume.SecretOption `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+}
+
+// RunOptions returns the options to start the job on nodeName
+func (s *Spec) RunOptions(nodeName string) volume.RunOptions {
+	return volume.RunOptions{
+		NodeName:   nodeName,
+		VolumeName: s.VolumeName,
+		GPUs:       s.GPUs,
+		GPUMem:     s.GPUMem,
+		GPUType:    s.GPUType,
+		Command:    s.Command,
+		Mounts:     s.Mounts,
+		PinCPU:     s.PinCPU,
+		PinMem:     s.PinMem,
+		Env:        s.Env,
+		Secrets:    s.Secrets,
+	}
+}
+
+// Job is a queued run session
+type Job struct {
+	ID       string `json:"id" yaml:"id"`
+	Position int    `json:"position" yaml:"position"` // 1 = next in line
+	State    string `json:"state" yaml:"state"`
+	Message  string `json:"message,omitempty" yaml:"message,omitempty"` // Why the job failed
+
+	Spec `yaml:",inline"`
+
+	Age         string    `json:"age" yaml:"age"`
+	SubmittedAt time.Time `json:"submittedAt" yaml:"submittedAt"`
+}
+
+// Submit adds a job to the end of the queue and returns it
+func Submit(ctx context.Context, c *client.Client, spec Spec) (*Job, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "job-",
+			Namespace:    c.Namespace,
+			Labels: map[string]string{
+				sgs.LabelManagedBy:  sgs.LabelManagedByValue,
+				sgs.LabelQueueJob:   "true",
+				sgs.LabelNodeName:   spec.NodeName,
+				sgs.LabelVolumeName: spec.VolumeName,
+			},
+			Annotations: map[string]string{
+				sgs.AnnotationQueueState: StateQueued,
+				// Submission time orders the queue until it is reordered
+				sgs.AnnotationQueueOrder: strconv.FormatInt(time.Now().UnixNano(), 10),
+			},
+		},
+		Data: map[string]string{jobKey: string(data)},
+	}
+
+	created, err := c.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "create", "job", c.Namespace)
+	}
+	return Get(ctx, c, created.Name)
+}
+
+// List returns the jobs of the current workspace in queue order
+func List(ctx context.Context, c *client.Client) ([]Job, error) {
+	cms, err := listConfigMaps(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(cms))
+	for i := range cms {
+		job, err := toJob(&cms[i])
+		if err != nil {
+			return nil, err
+		}
+		job.Position = i + 1
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// Get returns a job by ID
+func Get(ctx context.Context, c *client.Client, id string) (*Job, error) {
+	jobs, err := List(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("job %q not found (see 'sgs queue list')", id)
+}
+
+// Delete removes a job from the queue. Sessions it started are not affected.
+func Delete(ctx context.Context, c *client.Client, id string) error {
+	if _, err := Get(ctx, c, id); err != nil {
+		return err
+	}
+	err := c.Clientset.CoreV1().ConfigMaps(c.Namespace).Delete(ctx, id, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return client.FormatK8sError(err, "delete", "job", c.Namespace)
+	}
+	return nil
+}
+
+// MarkFailed keeps a job that could not be started in the queue with the
+// reason, so that it is skipped until cancelled
+func MarkFailed(ctx context.Context, c *client.Client, id, message string) error {
+	return patchAnnotations(ctx, c, id, map[string]any{
+		sgs.AnnotationQueueState:   StateFailed,
+		sgs.AnnotationQueueMessage: message,
+	})
+}
+
+// Reorder moves a job to position (1 = next in line). Positions past the end
+// move the job to the end of the queue.
+func Reorder(ctx context.Context, c *client.Client, id string, position int) error {
+	if position < 1 {
+		return fmt.Errorf("invalid position %d: positions start at 1", position)
+	}
+
+	cms, err := listConfigMaps(ctx, c)
+	if err != nil {
+		return err
+	}
+	from := -1
+	for i := range cms {
+		if cms[i].Name == id {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return fmt.Errorf("job %q not found (see 'sgs queue list')", id)
+	}
+
+	moved := cms[from]
+	cms = append(cms[:from], cms[from+1:]...)
+	to := min(position-1, len(cms))
+	cms = append(cms[:to], append([]corev1.ConfigMap{moved}, cms[to:]...)...)
+
+	// Renumber the jobs whose order changed
+	for i := range cms {
+		order := strconv.Itoa(i + 1)
+		if cms[i].Annotations[sgs.AnnotationQueueOrder] == order {
+			continue
+		}
+		if err := patchAnnotations(ctx, c, cms[i].Name, map[string]any{sgs.AnnotationQueueOrder: order}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listConfigMaps returns the job ConfigMaps in queue order
+func listConfigMaps(ctx context.Context, c *client.Client) ([]corev1.ConfigMap, error) {
+	list, err := client.RetryWithContext(ctx, func() (*corev1.ConfigMapList, error) {
+		return c.Clientset.CoreV1().ConfigMaps(c.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s", sgs.LabelManagedBy, sgs.LabelManagedByValue, sgs.LabelQueueJob),
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "jobs", c.Namespace)
+	}
+
+	cms := list.Items
+	sort.SliceStable(cms, func(i, j int) bool {
+		a, b := order(&cms[i]), order(&cms[j])
+		if a != b {
+			return a < b
+		}
+		return cms[i].CreationTimestamp.Before(&cms[j].CreationTimestamp)
+	})
+	return cms, nil
+}
+
+// order returns the sort key of a job ConfigMap
+func order(cm *corev1.ConfigMap) int64 {
+	n, _ := strconv.ParseInt(cm.Annotations[sgs.AnnotationQueueOrder], 10, 64)
+	return n
+}
+
+// toJob converts a job ConfigMap to a Job, without its position
+func toJob(cm *corev1.ConfigMap) (*Job, error) {
+	job := &Job{
+		ID:          cm.Name,
+		State:       cm.Annotations[sgs.AnnotationQueueState],
+		Message:     cm.Annotations[sgs.AnnotationQueueMessage],
+		Age:         sgs.FormatAge(time.Since(cm.CreationTimestamp.Time)),
+		SubmittedAt: cm.CreationTimestamp.Time,
+	}
+	if job.State == "" {
+		job.State = StateQueued
+	}
+	if err := json.Unmarshal([]byte(cm.Data[jobKey]), &job.Spec); err != nil {
+		return nil, fmt.Errorf("job %q is corrupt: %w", cm.Name, err)
+	}
+	return job, nil
+}
+
+// patchAnnotations merges annotations into a job ConfigMap
+func patchAnnotations(ctx context.Context, c *client.Client, id string, annotations map[string]any) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.Clientset.CoreV1().ConfigMaps(c.Namespace).Patch(ctx, id, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return client.FormatK8sError(err, "update", "job", c.Namespace)
+	}
+	return nil
+}
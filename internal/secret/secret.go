@@ -0,0 +1,198 @@
+// Package secret manages SGS secrets: Kubernetes Secrets in the workspace
+// namespace that sessions can use as environment variables or files.
+package secret
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretInfo represents an SGS secret. Values are never included.
+type SecretInfo struct {
+	Name      string    `json:"name" yaml:"name"`
+	Keys      []string  `json:"keys" yaml:"keys"`
+	Age       string    `json:"age" yaml:"age"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// isSGSSecret reports whether a Secret was created by sgs
+func isSGSSecret(s *corev1.Secret) bool {
+	return s.Labels[sgs.LabelManagedBy] == sgs.LabelManagedByValue && s.Labels[sgs.LabelSecretName] != ""
+}
+
+// Create creates a secret holding data. With replace, an existing SGS secret
+// of the same name is overwritten; Secrets not created by sgs never are.
+func Create(ctx context.Context, c *client.Client, name string, data map[string]string, replace bool) error {
+	if len(data) == 0 {
+		return fmt.Errorf("secret %q has no keys", name)
+	}
+
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				sgs.LabelManagedBy:  sgs.LabelManagedByValue,
+				sgs.LabelSecretName: name,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: data,
+	}
+
+	_, err := c.Clientset.CoreV1().Secrets(c.Namespace).Create(ctx, s, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return client.FormatK8sError(err, "create", "secret", c.Namespace)
+	}
+
+	existing, getErr := c.Clientset.CoreV1().Secrets(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if getErr != nil {
+		return client.FormatK8sError(getErr, "get", "secret", c.Namespace)
+	}
+	if !isSGSSecret(existing) {
+		return fmt.Errorf("a secret named %q exists but was not created by sgs; choose another name", name)
+	}
+	if !replace {
+		return fmt.Errorf("secret %q already exists (use --replace to overwrite it)", name)
+	}
+	existing.Data = nil
+	existing.StringData = data
+	if _, err := c.Clientset.CoreV1().Secrets(c.Namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return client.FormatK8sError(err, "update", "secret", c.Namespace)
+	}
+	return nil
+}
+
+// List returns the SGS secrets in the current workspace, sorted by name
+func List(ctx context.Context, c *client.Client) ([]SecretInfo, error) {
+	secrets, err := client.RetryWithContext(ctx, func() (*corev1.SecretList, error) {
+		return c.Clientset.CoreV1().Secrets(c.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s", sgs.LabelManagedBy, sgs.LabelManagedByValue, sgs.LabelSecretName),
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "secrets", c.Namespace)
+	}
+
+	result := make([]SecretInfo, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		result = append(result, toInfo(&secrets.Items[i]))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Get returns an SGS secret by name
+func Get(ctx context.Context, c *client.Client, name string) (*SecretInfo, error) {
+	s, err := client.RetryWithContext(ctx, func() (*corev1.Secret, error) {
+		return c.Clientset.CoreV1().Secrets(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("secret %q not found (see 'sgs secret list')", name)
+		}
+		return nil, client.FormatK8sError(err, "get", "secret", c.Namespace)
+	}
+	if !isSGSSecret(s) {
+		return nil, fmt.Errorf("secret %q was not created by sgs", name)
+	}
+	info := toInfo(s)
+	return &info, nil
+}
+
+// Delete deletes an SGS secret. Sessions already using it keep their copy
+// until they are restarted.
+func Delete(ctx context.Context, c *client.Client, name string) error {
+	if _, err := Get(ctx, c, name); err != nil {
+		return err
+	}
+	if err := c.Clientset.CoreV1().Secrets(c.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return client.FormatK8sError(err, "delete", "secret", c.Namespace)
+	}
+	return nil
+}
+
+// toInfo converts a Secret to SecretInfo, leaving out the values
+func toInfo(s *corev1.Secret) SecretInfo {
+	keys := make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return SecretInfo{
+		Name:      s.Name,
+		Keys:      keys,
+		Age:       sgs.FormatAge(time.Since(s.CreationTimestamp.Time)),
+		CreatedAt: s.CreationTimestamp.Time,
+	}
+}
+
+// ParseEnvFile parses a .env file: KEY=VALUE lines, with blank lines and
+// # comments ignored. An "export " prefix is allowed, and values may be
+// quoted; double-quoted values support escapes such as \n.
+func ParseEnvFile(data string) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !ValidEnvName(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value", lineNum)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// Unquoted values may end with a comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// ValidEnvName reports whether name can be used as an environment variable name
+func ValidEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// EventInfo is a Kubernetes event recorded for a session pod
+type EventInfo struct {
+	Type     string    `json:"type" yaml:"type"` // Normal or Warning
+	Reason   string    `json:"reason" yaml:"reason"`
+	Message  string    `json:"message" yaml:"message"`
+	Count    int32     `json:"count" yaml:"count"`
+	Age      string    `json:"age" yaml:"age"` // Since the last occurrence
+	LastSeen time.Time `json:"lastSeen" yaml:"lastSeen"`
+}
+
+// Events returns the events recorded for a session pod, oldest first
+func Events(ctx context.Context, c *client.Client, sessionName string) ([]EventInfo, error) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": sessionName,
+	}.AsSelector().String()
+
+	events, err := client.RetryWithContext(ctx, func() (*corev1.EventList, error) {
+		return c.Clientset.CoreV1().Events(c.Namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: selector,
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "events", c.Namespace)
+	}
+
+	var result []EventInfo
+	for _, e := range events.Items {
+		// Depending on the reporter, the time is in one of these fields
+		lastSeen := e.LastTimestamp.Time
+		if lastSeen.IsZero() {
+			lastSeen = e.EventTime.Time
+		}
+		if lastSeen.IsZero() {
+			lastSeen = e.CreationTimestamp.Time
+		}
+		result = append(result, EventInfo{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Message:  strings.TrimSpace(e.Message),
+			Count:    max(e.Count, 1),
+			Age:      sgs.FormatAge(time.Since(lastSeen)),
+			LastSeen: lastSeen,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.Before(result[j].LastSeen)
+	})
+	return result, nil
+}
+
+// Diagnose explains in plain words why a session is not running normally,
+// based on its container state and events. It returns nothing for healthy sessions.
+func Diagnose(s SessionInfo, events []EventInfo) []string {
+	var findings []string
+	sessionPath := s.Node + "/" + s.VolumeName
+
+	switch s.Reason {
+	case "Unschedulable":
+		finding := "The session cannot be scheduled: " + s.Message
+		if strings.Contains(s.Message, "nvidia.com/gpu") {
+			finding += fmt.Sprintf("\nNot enough free GPUs or GPU memory on %s. Check 'sgs get node %s' or wait for other sessions to finish.", s.Node, s.Node)
+		}
+		findings = append(findings, finding)
+	case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+		findings = append(findings, "The container image cannot be pulled: "+s.Message+
+			"\nCheck the image name of the OS volume and that the registry is reachable.")
+	case "CrashLoopBackOff":
+		findings = append(findings, fmt.Sprintf("The container keeps exiting (restarted %d times). See the output of the last attempt with 'sgs logs %s --previous'.", s.Restarts, sessionPath))
+	case "OOMKilled":
+		findings = append(findings, "The container was killed because it ran out of memory (OOMKilled).\nReduce memory usage (e.g. batch size or data loader workers) or request a node with more memory.")
+	case "Evicted":
+		findings = append(findings, "The session was evicted by the node: "+s.Message)
+	case "ContainerCannotRun", "CreateContainerError", "CreateContainerConfigError", "RunContainerError":
+		findings = append(findings, "The container could not be started: "+s.Message)
+	}
+
+	// Exit codes not explained by a reason above
+	if s.ExitCode != nil && *s.ExitCode != 0 && s.Reason != "OOMKilled" {
+		switch *s.ExitCode {
+		case 137:
+			findings = append(findings, "The command was killed with SIGKILL (exit code 137), e.g. by running out of memory or the session being deleted.")
+		case 143:
+			findings = append(findings, "The command was terminated with SIGTERM (exit code 143), e.g. by the session being deleted.")
+		default:
+			findings = append(findings, fmt.Sprintf("The command exited with code %d. See its output with 'sgs logs %s'.", *s.ExitCode, sessionPath))
+		}
+	}
+
+	// Problems that only show up in events
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if e.Type != corev1.EventTypeWarning || seen[e.Reason] {
+			continue
+		}
+		if e.Reason == "FailedMount" || e.Reason == "FailedAttachVolume" {
+			findings = append(findings, "The volume cannot be mounted: "+e.Message)
+			seen[e.Reason] = true
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,210 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxLogRetries is the number of consecutive reconnect attempts before
+// following logs gives up
+const maxLogRetries = 5
+
+// Logs streams logs from a session to out. With opts.Follow, it keeps streaming
+// until the session terminates, reconnecting when the stream drops.
+func Logs(ctx context.Context, c *client.Client, sessionName string, opts LogsOptions, out io.Writer) error {
+	// Verify it's an SGS session
+	_, err := Get(ctx, c, sessionName)
+	if err != nil {
+		return err
+	}
+
+	logOpts := &corev1.PodLogOptions{
+		Container:  "main",
+		Follow:     opts.Follow,
+		Previous:   opts.Previous,
+		Timestamps: opts.Timestamps,
+	}
+	if opts.Tail >= 0 {
+		logOpts.TailLines = &opts.Tail
+	}
+	if opts.Since > 0 {
+		sinceSeconds := max(int64(opts.Since.Seconds()), 1)
+		logOpts.SinceSeconds = &sinceSeconds
+	}
+
+	// A previous container has already exited, so there is nothing to reconnect to
+	if opts.Follow && !opts.Previous {
+		return followLogs(ctx, c, sessionName, logOpts, out)
+	}
+
+	stream, err := c.Clientset.CoreV1().Pods(c.Namespace).GetLogs(sessionName, logOpts).Stream(ctx)
+	if err != nil {
+		return client.FormatK8sError(err, "get", "logs", c.Namespace)
+	}
+	defer stream.Close()
+
+	if _, err := io.Copy(out, stream); err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+	return nil
+}
+
+// FollowPodLogs streams the logs of a container of any SGS pod to out until
+// the pod terminates
+func FollowPodLogs(ctx context.Context, c *client.Client, podName, container string, out io.Writer) error {
+	return followLogs(ctx, c, podName, &corev1.PodLogOptions{Container: container, Follow: true}, out)
+}
+
+// followLogs streams logs until the pod terminates. Timestamps are always
+// requested so that a reconnect can resume after the last line written; they
+// are stripped again unless the caller asked for them.
+func followLogs(ctx context.Context, c *client.Client, podName string, logOpts *corev1.PodLogOptions, out io.Writer) error {
+	showTimestamps := logOpts.Timestamps
+	logOpts.Timestamps = true
+
+	var pos logPosition
+	failures := 0
+	for attempt := 0; ; attempt++ {
+		stream, err := c.Clientset.CoreV1().Pods(c.Namespace).GetLogs(podName, logOpts).Stream(ctx)
+		if err != nil && attempt == 0 {
+			return client.FormatK8sError(err, "get", "logs", c.Namespace)
+		}
+		if err == nil {
+			var written int
+			written, err = pos.copyLines(stream, out, showTimestamps)
+			stream.Close()
+			if written > 0 {
+				failures = 0
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// The stream also ends when the container exits, so check the pod first
+		pod, getErr := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			return fmt.Errorf("session %q was deleted", podName)
+		}
+		if getErr == nil && (pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed) {
+			return nil
+		}
+
+		if err != nil {
+			failures++
+			if failures > maxLogRetries {
+				return fmt.Errorf("log stream failed after %d attempts: %w", maxLogRetries, err)
+			}
+		}
+
+		// Resume after the last line written instead of replaying tail/since.
+		// Until a line was written, ask for the same lines again.
+		if !pos.last.IsZero() {
+			logOpts.TailLines = nil
+			logOpts.SinceSeconds = nil
+			logOpts.SinceTime = &metav1.Time{Time: pos.last}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(failures+1) * time.Second):
+		}
+	}
+}
+
+// logPosition is the position in a log stream up to which lines were written.
+// Several lines can share a timestamp, so the lines written at the last one
+// are kept to tell them apart from new lines when a reconnect replays them.
+type logPosition struct {
+	last time.Time      // Timestamp of the last line written
+	seen map[string]int // Lines written at last, with their counts
+}
+
+// copyLines copies timestamped log lines from r to out, skipping lines that
+// were already written before a reconnect. It returns the number of lines
+// written.
+func (p *logPosition) copyLines(r io.Reader, out io.Writer, showTimestamps bool) (int, error) {
+	// Lines at p.last that this stream replays
+	replayed := make(map[string]int, len(p.seen))
+	for line, n := range p.seen {
+		replayed[line] = n
+	}
+
+	reader := bufio.NewReader(r)
+	written := 0
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			write := line
+			timestamp, rest, found := strings.Cut(line, " ")
+			t, parseErr := time.Parse(time.RFC3339Nano, timestamp)
+			switch {
+			case !found || parseErr != nil:
+				// Not timestamped, write as is
+			case t.Before(p.last):
+				write = ""
+			case t.Equal(p.last) && replayed[line] > 0:
+				replayed[line]--
+				write = ""
+			default:
+				if t.After(p.last) || p.seen == nil {
+					p.last = t
+					p.seen = make(map[string]int)
+					replayed = nil
+				}
+				p.seen[line]++
+				if !showTimestamps {
+					write = rest
+				}
+			}
+			if write != "" {
+				if _, err := io.WriteString(out, write); err != nil {
+					return written, err
+				}
+				written++
+			}
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// ExitCode returns the exit code of a session's main container, and false if
+// the session has not terminated yet
+func ExitCode(ctx context.Context, c *client.Client, sessionName string) (int, bool, error) {
+	pod, err := client.RetryWithContext(ctx, func() (*corev1.Pod, error) {
+		return c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, sessionName, metav1.GetOptions{})
+	})
+	if err != nil {
+		return 0, false, client.FormatK8sError(err, "get", "session", c.Namespace)
+	}
+
+	if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		return 0, false, nil
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == "main" && cs.State.Terminated != nil {
+			return int(cs.State.Terminated.ExitCode), true, nil
+		}
+	}
+
+	// Failed without a container exit code (e.g. evicted)
+	if pod.Status.Phase == corev1.PodFailed {
+		return 1, true, nil
+	}
+	return 0, true, nil
+}
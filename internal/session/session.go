@@ -2,7 +2,6 @@
 package session
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -27,21 +26,33 @@ var (
 
 // SessionInfo represents information about an SGS session (running pod)
 type SessionInfo struct {
-	PodName    string // Internal pod name
-	VolumeName string // Volume name without node prefix
-	Type       SessionType
-	Node       string
-	Status     string
-	GPUs       int
-	GPUMem     int64 // GPU memory in MiB (HAMi)
-	Age        string
-	Command    string // Command being run (for run sessions)
+	PodName    string      `json:"podName" yaml:"podName"`       // Internal pod name
+	VolumeName string      `json:"volumeName" yaml:"volumeName"` // Volume name without node prefix
+	Type       SessionType `json:"type" yaml:"type"`
+	Node       string      `json:"node" yaml:"node"`
+	Status     string      `json:"status" yaml:"status"`
+	GPUs       int         `json:"gpus" yaml:"gpus"`
+	GPUMem     int64       `json:"gpuMemMiB" yaml:"gpuMemMiB"` // GPU memory in MiB (HAMi)
+	Age        string      `json:"age" yaml:"age"`
+	CreatedAt  time.Time   `json:"createdAt" yaml:"createdAt"`
+	Command    string      `json:"command,omitempty" yaml:"command,omitempty"` // Command being run (for run sessions)
+
+	// Container state, for diagnosing sessions that are pending or have failed
+	Reason     string     `json:"reason,omitempty" yaml:"reason,omitempty"`   // Why the session is in its status (e.g. Unschedulable, ImagePullBackOff, OOMKilled)
+	Message    string     `json:"message,omitempty" yaml:"message,omitempty"` // Details for Reason
+	ExitCode   *int32     `json:"exitCode,omitempty" yaml:"exitCode,omitempty"`
+	Restarts   int32      `json:"restarts" yaml:"restarts"`
+	StartedAt  *time.Time `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" yaml:"finishedAt,omitempty"`
 }
 
 // LogsOptions holds options for getting logs
 type LogsOptions struct {
-	Follow bool
-	Tail   int64
+	Follow     bool
+	Tail       int64         // Lines of recent log to show (-1 = all)
+	Since      time.Duration // Only show logs newer than this (0 = all)
+	Timestamps bool          // Prefix each line with its RFC3339 timestamp
+	Previous   bool          // Logs of the previous container instance (after a restart)
 }
 
 // List returns all sessions (pods) in the current namespace
@@ -64,11 +75,11 @@ func List(ctx context.Context, c *client.Client) ([]SessionInfo, error) {
 	return sessions, nil
 }
 
-// ListByVolume returns all sessions for a specific volume
-func ListByVolume(ctx context.Context, c *client.Client, volumeName string) ([]SessionInfo, error) {
+// ListByVolume returns all sessions for a specific volume, by its PVC name (<node>-<volume>)
+func ListByVolume(ctx context.Context, c *client.Client, pvcName string) ([]SessionInfo, error) {
 	pods, err := client.RetryWithContext(ctx, func() (*corev1.PodList, error) {
 		return c.Clientset.CoreV1().Pods(c.Namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("%s=sgs,%s=%s,%s", sgs.LabelManagedBy, sgs.LabelVolumeName, volumeName, sgs.LabelSessionMode),
+			LabelSelector: fmt.Sprintf("%s=sgs,%s=%s,%s", sgs.LabelManagedBy, sgs.LabelVolumeName, pvcName, sgs.LabelSessionMode),
 		})
 	})
 	if err != nil {
@@ -124,46 +135,14 @@ func Get(ctx context.Context, c *client.Client, sessionName string) (*SessionInf
 	return &info, nil
 }
 
-// Logs returns logs from a session
-func Logs(ctx context.Context, c *client.Client, sessionName string, opts LogsOptions) (string, error) {
-	// Verify it's an SGS session
-	_, err := Get(ctx, c, sessionName)
-	if err != nil {
-		return "", err
-	}
-
-	logOpts := &corev1.PodLogOptions{
-		Container: "main",
-	}
-	if opts.Follow {
-		logOpts.Follow = true
-	}
-	if opts.Tail >= 0 {
-		logOpts.TailLines = &opts.Tail
-	}
-
-	req := c.Clientset.CoreV1().Pods(c.Namespace).GetLogs(sessionName, logOpts)
-	stream, err := req.Stream(ctx)
-	if err != nil {
-		return "", client.FormatK8sError(err, "get", "logs", c.Namespace)
-	}
-	defer stream.Close()
-
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(stream); err != nil {
-		return "", fmt.Errorf("failed to read logs: %w", err)
-	}
-
-	return buf.String(), nil
-}
-
 // podToSessionInfo converts a pod to SessionInfo
 func podToSessionInfo(pod *corev1.Pod) SessionInfo {
 	info := SessionInfo{
-		PodName: pod.Name,
-		Node:    pod.Spec.NodeName,
-		Status:  string(pod.Status.Phase),
-		Age:     formatAge(time.Since(pod.CreationTimestamp.Time)),
+		PodName:   pod.Name,
+		Node:      pod.Spec.NodeName,
+		Status:    string(pod.Status.Phase),
+		Age:       sgs.FormatAge(time.Since(pod.CreationTimestamp.Time)),
+		CreatedAt: pod.CreationTimestamp.Time,
 	}
 
 	// Get volume name from label (this is the PVC name: <node>-<volume>)
@@ -224,19 +203,42 @@ func podToSessionInfo(pod *corev1.Pod) SessionInfo {
 		}
 	}
 
+	setContainerState(&info, pod)
+
 	return info
 }
 
-// formatAge formats a duration into a human-readable age string
-func formatAge(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
+// setContainerState fills in the state of the main container and, for sessions
+// that are not running normally, the reason why
+func setContainerState(info *SessionInfo, pod *corev1.Pod) {
+	// Not scheduled yet (e.g. not enough free GPUs on the node)
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			info.Reason, info.Message = cond.Reason, cond.Message
+		}
 	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != "main" {
+			continue
+		}
+		info.Restarts = cs.RestartCount
+		switch {
+		case cs.State.Running != nil:
+			info.StartedAt = &cs.State.Running.StartedAt.Time
+		case cs.State.Terminated != nil:
+			t := cs.State.Terminated
+			info.Reason, info.Message = t.Reason, t.Message
+			info.ExitCode = &t.ExitCode
+			info.StartedAt = &t.StartedAt.Time
+			info.FinishedAt = &t.FinishedAt.Time
+		case cs.State.Waiting != nil:
+			info.Reason, info.Message = cs.State.Waiting.Reason, cs.State.Waiting.Message
+		}
 	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
+
+	// Pod-level reasons (e.g. Evicted) override the container state
+	if pod.Status.Reason != "" {
+		info.Reason, info.Message = pod.Status.Reason, pod.Status.Message
 	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
 }
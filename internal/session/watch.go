@@ -0,0 +1,57 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Watch calls fn for every current session (as watch.Added) and then for every
+// change to a session until ctx is cancelled
+func Watch(ctx context.Context, c *client.Client, fn func(watch.EventType, SessionInfo)) error {
+	opts := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=sgs,%s", sgs.LabelManagedBy, sgs.LabelSessionMode),
+	}
+	pods, err := client.RetryWithContext(ctx, func() (*corev1.PodList, error) {
+		return c.Clientset.CoreV1().Pods(c.Namespace).List(ctx, opts)
+	})
+	if err != nil {
+		return client.FormatK8sError(err, "list", "sessions", c.Namespace)
+	}
+
+	for i := range pods.Items {
+		fn(watch.Added, podToSessionInfo(&pods.Items[i]))
+	}
+
+	w, err := client.NewRetryWatcher(ctx, pods.ResourceVersion, opts, c.Clientset.CoreV1().Pods(c.Namespace).Watch)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("watch on sessions ended unexpectedly")
+			}
+			if event.Type == watch.Error {
+				return client.FormatK8sError(errors.FromObject(event.Object), "watch", "sessions", c.Namespace)
+			}
+			if pod, ok := event.Object.(*corev1.Pod); ok {
+				fn(event.Type, podToSessionInfo(pod))
+			}
+		}
+	}
+}
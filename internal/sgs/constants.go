@@ -11,6 +11,11 @@ const (
 	LabelVolumeName     = "sgs.snucse.org/volume-name"
 	LabelSessionMode    = "sgs.snucse.org/session-mode"
 	LabelWorkspaceID    = "sgs.snucse.org/id"
+	LabelSnapshotName   = "sgs.snucse.org/snapshot-name"
+	LabelSnapshotOf     = "sgs.snucse.org/snapshot-of" // Source volume of a tar snapshot volume
+	LabelSecretName     = "sgs.snucse.org/secret-name" // Marks Secrets created with 'sgs secret create'
+	LabelQueueJob       = "sgs.snucse.org/queue-job"   // Marks ConfigMaps holding jobs of 'sgs submit'
+	LabelSweepName      = "sgs.snucse.org/sweep-name"  // Marks ConfigMaps holding manifests of 'sgs sweep'
 )
 
 // Annotation keys for Kubernetes resources
@@ -18,6 +23,30 @@ const (
 	AnnotationSelectedNode = "volume.kubernetes.io/selected-node"
 	AnnotationOSImage      = "sgs.snucse.org/os-image"
 	AnnotationNodeSelector = "scheduler.alpha.kubernetes.io/node-selector"
+
+	// Image an OS volume was rebased from (see 'sgs rebase volume')
+	AnnotationPreviousOSImage = "sgs.snucse.org/previous-os-image"
+
+	// Set while the setup script of an OS volume runs ("running") or after it
+	// failed ("failed"); removed once the volume is ready
+	AnnotationSetup = "sgs.snucse.org/setup"
+
+	// Snapshots keep the OS image, storage class and size of their source
+	// volume, so that restored volumes stay bootable
+	AnnotationSnapshotImage        = "sgs.snucse.org/snapshot-os-image"
+	AnnotationSnapshotStorageClass = "sgs.snucse.org/snapshot-storage-class"
+	AnnotationSnapshotSize         = "sgs.snucse.org/snapshot-size"
+	AnnotationSnapshotComplete     = "sgs.snucse.org/snapshot-complete"
+
+	// Queued jobs (see 'sgs submit') are ordered by AnnotationQueueOrder and
+	// carry their state, and why they failed to start
+	AnnotationQueueOrder   = "sgs.snucse.org/queue-order"
+	AnnotationQueueState   = "sgs.snucse.org/queue-state"
+	AnnotationQueueMessage = "sgs.snucse.org/queue-message"
+
+	// Set to the sweep name on the clones of a sweep (see 'sgs sweep') once
+	// they are completely copied
+	AnnotationSweep = "sgs.snucse.org/sweep"
 )
 
 // Session modes
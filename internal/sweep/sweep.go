@@ -0,0 +1,550 @@
+// Package sweep runs hyperparameter sweeps: one run session per combination
+// of parameter values, each on its own clone of a base OS volume. A sweep's
+// runs and their results are tracked in a manifest stored in the workspace.
+package sweep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/queue"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	"github.com/bacchus-snu/sgs-cli/internal/volume"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Run states
+const (
+	StatusPending   = "pending"   // Not started yet
+	StatusQueued    = "queued"    // Waiting in the job queue (see 'sgs submit')
+	StatusRunning   = "running"   // Session started
+	StatusSucceeded = "succeeded" // Exited with code 0
+	StatusFailed    = "failed"    // Exited with a non-zero code
+	StatusError     = "error"     // Could not be cloned or started; see Message
+)
+
+const (
+	configMapPrefix = "sweep-"
+	manifestKey     = "manifest"
+)
+
+// placeholderRe matches {key} placeholders in a command, and shell parameter
+// expansions such as ${HOME} so that they can be left alone
+var placeholderRe = regexp.MustCompile(`\$?\{([A-Za-z_][A-Za-z0-9_-]*)\}`)
+
+// Param is a swept parameter and its values
+type Param struct {
+	Key    string   `json:"key" yaml:"key"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// Run is one combination of parameter values and its result
+type Run struct {
+	Index    int               `json:"index" yaml:"index"`
+	Params   map[string]string `json:"params" yaml:"params"`
+	Volume   string            `json:"volume" yaml:"volume"` // Clone of the base volume, on the same node
+	Command  string            `json:"command" yaml:"command"`
+	Status   string            `json:"status" yaml:"status"`
+	JobID    string            `json:"jobID,omitempty" yaml:"jobID,omitempty"` // Queue job, with --queue
+	ExitCode *int              `json:"exitCode,omitempty" yaml:"exitCode,omitempty"`
+	Message  string            `json:"message,omitempty" yaml:"message,omitempty"`
+	LogTail  []string          `json:"logTail,omitempty" yaml:"logTail,omitempty"`
+}
+
+// Manifest describes a sweep and tracks its runs
+type Manifest struct {
+	Name       string    `json:"name" yaml:"name"`
+	NodeName   string    `json:"node" yaml:"node"`
+	BaseVolume string    `json:"baseVolume" yaml:"baseVolume"`
+	Command    string    `json:"command" yaml:"command"` // With {key} placeholders
+	Grid       []Param   `json:"grid" yaml:"grid"`
+	GPUs       int       `json:"gpus" yaml:"gpus"`
+	GPUMem     int64     `json:"gpuMemMiB" yaml:"gpuMemMiB"`
+	Runs       []Run     `json:"runs" yaml:"runs"`
+	CreatedAt  time.Time `json:"createdAt" yaml:"createdAt"`
+
+	// Session options shared by all runs, kept to start pending runs later
+	GPUType string                `json:"gpuType,omitempty" yaml:"gpuType,omitempty"`
+	Mounts  []volume.MountOption  `json:"mounts,omitempty" yaml:"mounts,omitempty"`
+	PinCPU  int64                 `json:"pinCPU,omitempty" yaml:"pinCPU,omitempty"`
+	PinMem  int64                 `json:"pinMem,omitempty" yaml:"pinMem,omitempty"`
+	Env     map[string]string     `json:"env,omitempty" yaml:"env,omitempty"`
+	Secrets []volume.SecretOption `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	Queue   bool                  `json:"queue,omitempty" yaml:"queue,omitempty"` // Runs go through the job queue
+}
+
+// Counts returns the number of runs in each state
+func (m *Manifest) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range m.Runs {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// Active reports whether any run is queued or running. Pending runs don't
+// count: they only start when the sweep is launched or resumed.
+func (m *Manifest) Active() bool {
+	for _, r := range m.Runs {
+		if r.Status == StatusQueued || r.Status == StatusRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGrid parses --grid values of the form key=v1,v2,...
+func ParseGrid(flags []string) ([]Param, error) {
+	var grid []Param
+	seen := make(map[string]bool)
+	for _, f := range flags {
+		key, values, found := strings.Cut(f, "=")
+		key = strings.TrimSpace(key)
+		if !found || placeholderRe.FindString("{"+key+"}") != "{"+key+"}" {
+			return nil, fmt.Errorf("invalid grid %q, expected key=value1,value2,...", f)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("parameter %q is given twice", key)
+		}
+		seen[key] = true
+
+		p := Param{Key: key}
+		for _, v := range strings.Split(values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				p.Values = append(p.Values, v)
+			}
+		}
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("parameter %q has no values", key)
+		}
+		grid = append(grid, p)
+	}
+	return grid, nil
+}
+
+// Placeholders returns the {key} placeholders of a command, in order of
+// first use
+func Placeholders(command string) []string {
+	var keys []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(command, -1) {
+		if !strings.HasPrefix(m[0], "$") && !slices.Contains(keys, m[1]) {
+			keys = append(keys, m[1])
+		}
+	}
+	return keys
+}
+
+// Expand returns every combination of the grid's values. The last parameter
+// varies fastest.
+func Expand(grid []Param) []map[string]string {
+	combos := []map[string]string{{}}
+	for _, p := range grid {
+		var next []map[string]string
+		for _, combo := range combos {
+			for _, v := range p.Values {
+				c := maps.Clone(combo)
+				c[p.Key] = v
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// Render substitutes the {key} placeholders of a command with params
+func Render(command string, params map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(command, func(m string) string {
+		if v, ok := params[strings.Trim(m, "{}")]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// FormatParams formats a run's parameters as "key=value ..." in grid order
+func FormatParams(grid []Param, params map[string]string) string {
+	parts := make([]string, 0, len(grid))
+	for _, p := range grid {
+		parts = append(parts, p.Key+"="+params[p.Key])
+	}
+	return strings.Join(parts, " ")
+}
+
+// New returns the manifest of a sweep over grid, with one pending run per
+// combination on volumes <name>-1, <name>-2, ... opts holds the session
+// options shared by all runs; its node, volume and command are ignored. With
+// useQueue, runs are submitted to the job queue instead of started.
+func New(name, nodeName, baseVolume, command string, grid []Param, opts volume.RunOptions, useQueue bool) *Manifest {
+	m := &Manifest{
+		Name:       name,
+		NodeName:   nodeName,
+		BaseVolume: baseVolume,
+		Command:    command,
+		Grid:       grid,
+		GPUs:       opts.GPUs,
+		GPUMem:     opts.GPUMem,
+		CreatedAt:  time.Now(),
+		GPUType:    opts.GPUType,
+		Mounts:     opts.Mounts,
+		PinCPU:     opts.PinCPU,
+		PinMem:     opts.PinMem,
+		Env:        opts.Env,
+		Secrets:    opts.Secrets,
+		Queue:      useQueue,
+	}
+	for i, params := range Expand(grid) {
+		m.Runs = append(m.Runs, Run{
+			Index:   i + 1,
+			Params:  params,
+			Volume:  fmt.Sprintf("%s-%d", name, i+1),
+			Command: Render(command, params),
+			Status:  StatusPending,
+		})
+	}
+	return m
+}
+
+// Create stores a new manifest. It fails if a sweep of the same name exists.
+func Create(ctx context.Context, c *client.Client, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode sweep manifest: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapPrefix + m.Name,
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				sgs.LabelManagedBy: sgs.LabelManagedByValue,
+				sgs.LabelSweepName: m.Name,
+			},
+		},
+		Data: map[string]string{manifestKey: string(data)},
+	}
+	if _, err := c.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("sweep %q already exists (use --name to choose another name)", m.Name)
+		}
+		return client.FormatK8sError(err, "create", "sweep", c.Namespace)
+	}
+	return nil
+}
+
+// Save stores the current state of a manifest
+func Save(ctx context.Context, c *client.Client, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode sweep manifest: %w", err)
+	}
+	cm, err := c.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(ctx, configMapPrefix+m.Name, metav1.GetOptions{})
+	if err != nil {
+		return client.FormatK8sError(err, "get", "sweep", c.Namespace)
+	}
+	cm.Data = map[string]string{manifestKey: string(data)}
+	if _, err := c.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return client.FormatK8sError(err, "update", "sweep", c.Namespace)
+	}
+	return nil
+}
+
+// Get returns the manifest of a sweep
+func Get(ctx context.Context, c *client.Client, name string) (*Manifest, error) {
+	cm, err := client.RetryWithContext(ctx, func() (*corev1.ConfigMap, error) {
+		return c.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(ctx, configMapPrefix+name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("sweep %q not found (see 'sgs sweep list')", name)
+		}
+		return nil, client.FormatK8sError(err, "get", "sweep", c.Namespace)
+	}
+	return toManifest(cm)
+}
+
+// List returns the sweeps of the current workspace, newest first
+func List(ctx context.Context, c *client.Client) ([]Manifest, error) {
+	cms, err := client.RetryWithContext(ctx, func() (*corev1.ConfigMapList, error) {
+		return c.Clientset.CoreV1().ConfigMaps(c.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s", sgs.LabelManagedBy, sgs.LabelManagedByValue, sgs.LabelSweepName),
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "sweeps", c.Namespace)
+	}
+
+	result := make([]Manifest, 0, len(cms.Items))
+	for i := range cms.Items {
+		m, err := toManifest(&cms.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// toManifest decodes the manifest stored in a ConfigMap
+func toManifest(cm *corev1.ConfigMap) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal([]byte(cm.Data[manifestKey]), &m); err != nil {
+		return nil, fmt.Errorf("sweep manifest %q is corrupt: %w", cm.Name, err)
+	}
+	return &m, nil
+}
+
+// Launch clones the base volume and starts a session for each pending run,
+// saving the manifest after each. A run that can't be cloned or started is
+// marked as an error and the others go on. Clones an interrupted launch
+// completed are used as they are, so Launch also resumes a sweep; any other
+// volume in the way of a clone is left alone and its run stays pending.
+func Launch(ctx context.Context, c *client.Client, m *Manifest) error {
+	for i := range m.Runs {
+		r := &m.Runs[i]
+		if r.Status != StatusPending {
+			continue
+		}
+
+		fmt.Printf("\n[%d/%d] %s\n", r.Index, len(m.Runs), FormatParams(m.Grid, r.Params))
+		resumed, err := cloneRun(ctx, c, m, r)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == errVolumeInTheWay {
+			continue
+		}
+		if err != nil {
+			r.Status, r.Message = StatusError, "clone failed: "+err.Error()
+		} else {
+			startRun(ctx, c, m, r, resumed)
+		}
+
+		if err := Save(ctx, c, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errVolumeInTheWay is returned by cloneRun when a volume that is not a
+// complete clone has the name of the clone
+var errVolumeInTheWay = fmt.Errorf("volume in the way of a clone")
+
+// cloneRun copies the base volume to the volume of a run, and marks the clone
+// complete once the copy succeeded. It returns true if a complete clone
+// already existed. A clone that is not marked complete, e.g. because sgs was
+// killed while copying, is not used, as it may be partial.
+func cloneRun(ctx context.Context, c *client.Client, m *Manifest, r *Run) (bool, error) {
+	pvcName := volume.PVCName(m.NodeName, r.Volume)
+	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	})
+	switch {
+	case err == nil && pvc.Annotations[sgs.AnnotationSweep] == m.Name:
+		fmt.Printf("Using existing clone %s/%s\n", m.NodeName, r.Volume)
+		return true, nil
+	case err == nil:
+		fmt.Fprintf(os.Stderr, "Warning: volume %s/%s is not a complete clone of this sweep (e.g. a copy cut short by a crash), so the run is not started; delete the volume if you don't need it, then resume the sweep\n",
+			m.NodeName, r.Volume)
+		return false, errVolumeInTheWay
+	case !errors.IsNotFound(err):
+		return false, client.FormatK8sError(err, "get", "volume", c.Namespace)
+	}
+
+	err = volume.Copy(ctx, c, volume.CopyOptions{
+		SrcNode:   m.NodeName,
+		SrcVolume: m.BaseVolume,
+		DstNode:   m.NodeName,
+		DstVolume: r.Volume,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{sgs.AnnotationSweep: m.Name},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode patch: %w", err)
+	}
+	if _, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Patch(ctx, pvcName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return false, client.FormatK8sError(err, "update", "volume", c.Namespace)
+	}
+	return false, nil
+}
+
+// startRun starts or queues the session of a cloned run. For a resumed run,
+// a job an earlier launch queued is kept rather than queued again; an
+// existing session is kept by volume.Run.
+func startRun(ctx context.Context, c *client.Client, m *Manifest, r *Run, resumed bool) {
+	opts := volume.RunOptions{
+		NodeName:   m.NodeName,
+		VolumeName: r.Volume,
+		GPUs:       m.GPUs,
+		GPUMem:     m.GPUMem,
+		GPUType:    m.GPUType,
+		Command:    []string{r.Command},
+		Mounts:     m.Mounts,
+		PinCPU:     m.PinCPU,
+		PinMem:     m.PinMem,
+		Env:        m.Env,
+		Secrets:    m.Secrets,
+	}
+
+	if m.Queue {
+		if resumed {
+			id, err := queuedJob(ctx, c, m.NodeName, r.Volume)
+			if err != nil {
+				r.Status, r.Message = StatusError, "submit failed: "+err.Error()
+				return
+			}
+			if id != "" {
+				r.Status, r.JobID = StatusQueued, id
+				fmt.Printf("Already queued as job %s\n", id)
+				return
+			}
+		}
+		job, err := queue.Submit(ctx, c, queue.Spec{
+			NodeName:   opts.NodeName,
+			VolumeName: opts.VolumeName,
+			GPUs:       opts.GPUs,
+			GPUMem:     opts.GPUMem,
+			GPUType:    opts.GPUType,
+			Command:    opts.Command,
+			Mounts:     opts.Mounts,
+			PinCPU:     opts.PinCPU,
+			PinMem:     opts.PinMem,
+			Env:        opts.Env,
+			Secrets:    opts.Secrets,
+		})
+		if err != nil {
+			r.Status, r.Message = StatusError, "submit failed: "+err.Error()
+			return
+		}
+		r.Status, r.JobID = StatusQueued, job.ID
+		fmt.Printf("Queued as job %s\n", job.ID)
+		return
+	}
+
+	if _, err := volume.Run(ctx, c, opts); err != nil {
+		r.Status, r.Message = StatusError, "start failed: "+err.Error()
+		return
+	}
+	r.Status = StatusRunning
+	fmt.Printf("Started run session %s/%s\n", m.NodeName, r.Volume)
+}
+
+// queuedJob returns the ID of the queued job for a volume, or "" if there is none
+func queuedJob(ctx context.Context, c *client.Client, nodeName, volumeName string) (string, error) {
+	jobs, err := queue.List(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	for _, j := range jobs {
+		if j.State == queue.StateQueued && j.NodeName == nodeName && j.VolumeName == volumeName {
+			return j.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// Refresh updates the status of unfinished runs from their sessions, and
+// records the exit code and the last tailLines lines of the log of runs that
+// terminated. The manifest is saved if anything changed.
+func Refresh(ctx context.Context, c *client.Client, m *Manifest, tailLines int64) error {
+	changed := false
+	for i := range m.Runs {
+		r := &m.Runs[i]
+		if r.Status != StatusQueued && r.Status != StatusRunning {
+			continue
+		}
+		before := r.Status
+		if err := refreshRun(ctx, c, m, r, tailLines); err != nil {
+			return err
+		}
+		changed = changed || r.Status != before
+	}
+	if !changed {
+		return nil
+	}
+	return Save(ctx, c, m)
+}
+
+// refreshRun updates the status of a queued or running run
+func refreshRun(ctx context.Context, c *client.Client, m *Manifest, r *Run, tailLines int64) error {
+	podName := volume.SessionPodName(m.NodeName, r.Volume)
+	getPod := func() error {
+		_, err := client.RetryWithContext(ctx, func() (*corev1.Pod, error) {
+			return c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		})
+		return err
+	}
+	err := getPod()
+	if errors.IsNotFound(err) {
+		if r.Status == StatusRunning {
+			r.Status, r.Message = StatusError, "session was deleted before it finished"
+			return nil
+		}
+		// Still queued, unless the job failed to start or was cancelled
+		jobs, err := queue.List(ctx, c)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if job.ID == r.JobID {
+				if job.State == queue.StateFailed {
+					r.Status, r.Message = StatusError, job.Message
+				}
+				return nil
+			}
+		}
+		// 'sgs queue run' removes a job once it created the session, so the
+		// session may have been created since it was looked up
+		err = getPod()
+		if errors.IsNotFound(err) {
+			r.Status, r.Message = StatusError, fmt.Sprintf("job %s left the queue without starting", r.JobID)
+			return nil
+		}
+	}
+	if err != nil {
+		return client.FormatK8sError(err, "get", "session", c.Namespace)
+	}
+
+	r.Status = StatusRunning
+	code, done, err := session.ExitCode(ctx, c, podName)
+	if err != nil || !done {
+		return err
+	}
+	r.ExitCode = &code
+	r.Status = StatusSucceeded
+	if code != 0 {
+		r.Status = StatusFailed
+	}
+
+	var buf bytes.Buffer
+	if err := session.Logs(ctx, c, podName, session.LogsOptions{Tail: tailLines}, &buf); err != nil {
+		r.Message = "logs unavailable: " + err.Error()
+		return nil
+	}
+	if tail := strings.TrimRight(buf.String(), "\n"); tail != "" {
+		r.LogTail = strings.Split(tail, "\n")
+	}
+	return nil
+}
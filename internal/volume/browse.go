@@ -0,0 +1,259 @@
+package volume
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FileInfo describes a file or directory in a volume
+type FileInfo struct {
+	Name    string    `json:"name" yaml:"name"`
+	Type    string    `json:"type" yaml:"type"` // file, dir, symlink or other
+	Mode    string    `json:"mode" yaml:"mode"` // e.g. -rw-r--r--
+	Size    int64     `json:"size" yaml:"size"`
+	ModTime time.Time `json:"modTime" yaml:"modTime"`
+}
+
+// DiskUsage is the disk space used by a directory in a volume
+type DiskUsage struct {
+	Path string `json:"path" yaml:"path"`
+	Size int64  `json:"size" yaml:"size"` // Bytes
+}
+
+// FindOptions filters the files found by Browser.Find
+type FindOptions struct {
+	Name     string // Shell pattern for the file name (empty = any)
+	Type     string // f (file), d (directory) or l (symlink); empty = any
+	MaxDepth int    // 0 = unlimited
+}
+
+// Browser runs read-only commands on the files of a volume. It uses the
+// volume's session if one is running, or else a helper pod that mounts the
+// volume read-only.
+type Browser struct {
+	c       *client.Client
+	podName string
+	root    string // Directory in the pod that volume paths are relative to
+	stop    func()
+}
+
+// OpenBrowser prepares to browse a volume. Status messages are written to
+// stderr so that output such as Cat can be redirected. Close must be called
+// when done.
+func OpenBrowser(ctx context.Context, c *client.Client, nodeName, volumeName string) (*Browser, error) {
+	info, err := Get(ctx, c, nodeName, volumeName)
+	if err != nil {
+		return nil, fmt.Errorf("volume %s not found", FormatVolumePath(nodeName, volumeName))
+	}
+
+	// A running session shows the volume as its root filesystem
+	mode, err := GetSessionMode(ctx, c, nodeName, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		podName := SessionPodName(nodeName, volumeName)
+		if err := waitForPodRunning(ctx, c, podName, 30*time.Second); err == nil {
+			return &Browser{c: c, podName: podName, root: "/", stop: func() {}}, nil
+		}
+	}
+
+	// Otherwise start a helper pod; for OS volumes it mounts upper/, which
+	// holds the files the user changed on top of the image
+	pod := createCopyPod("", nodeName, PVCName(nodeName, volumeName), c.Namespace, true, info.IsOSVolume)
+	pod.GenerateName = "browse-" + PVCName(nodeName, volumeName) + "-"
+	created, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "create", "helper pod", c.Namespace)
+	}
+	podName := created.Name
+
+	// Register cleanup for interrupt handling
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "  Cleaning up helper pod...")
+		if err := c.Clientset.CoreV1().Pods(c.Namespace).Delete(cleanupCtx, podName, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+	b := &Browser{c: c, podName: podName, root: "/data", stop: func() {
+		cleanup.Unregister()
+		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+	}}
+
+	fmt.Fprint(os.Stderr, "Starting helper pod...")
+	if err := waitForPodRunning(ctx, c, podName, 5*time.Minute); err != nil {
+		fmt.Fprintln(os.Stderr, " failed")
+		b.Close()
+		return nil, fmt.Errorf("helper pod failed to start: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, " done")
+	return b, nil
+}
+
+// Close deletes the helper pod, if one was started
+func (b *Browser) Close() {
+	b.stop()
+}
+
+// List returns the entries of the directory p sorted by name, or the file p itself
+func (b *Browser) List(ctx context.Context, p string) ([]FileInfo, error) {
+	// %f is the raw mode in hex, which includes the file type
+	script := `if [ -d "$1" ]; then cd "$1" && find . -mindepth 1 -maxdepth 1 -exec stat -c '%s %Y %f %n' {} +; else stat -c '%s %Y %f %n' "$1"; fi`
+	var stdout bytes.Buffer
+	if err := b.exec(ctx, []string{"sh", "-c", script, "sh", b.path(p)}, &stdout); err != nil {
+		return nil, err
+	}
+
+	var files []FileInfo
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[0], 10, 64)
+		modTime, _ := strconv.ParseInt(fields[1], 10, 64)
+		raw, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		fileType, mode := rawFileMode(raw)
+		files = append(files, FileInfo{
+			Name:    path.Base(fields[3]),
+			Type:    fileType,
+			Mode:    mode.String(),
+			Size:    size,
+			ModTime: time.Unix(modTime, 0),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// rawFileMode converts a raw stat mode to a file type name and an fs.FileMode
+func rawFileMode(raw uint64) (string, fs.FileMode) {
+	mode := fs.FileMode(raw & 0o777)
+	switch raw & 0o170000 {
+	case 0o100000:
+		return "file", mode
+	case 0o040000:
+		return "dir", mode | fs.ModeDir
+	case 0o120000:
+		return "symlink", mode | fs.ModeSymlink
+	case 0o010000:
+		return "other", mode | fs.ModeNamedPipe
+	case 0o140000:
+		return "other", mode | fs.ModeSocket
+	}
+	return "other", mode | fs.ModeDevice
+}
+
+// Cat writes the contents of the file p to w
+func (b *Browser) Cat(ctx context.Context, p string, w io.Writer) error {
+	return b.exec(ctx, []string{"cat", "--", b.path(p)}, w)
+}
+
+// DiskUsage returns the disk usage of p and of its directories down to depth
+// levels below it, largest first. Like Find, it stays on the file system of p,
+// so that in a session it skips /proc, /sys and the mounted volumes.
+func (b *Browser) DiskUsage(ctx context.Context, p string, depth int) ([]DiskUsage, error) {
+	var stdout bytes.Buffer
+	if err := b.exec(ctx, []string{"du", "-x", "-k", "-d", strconv.Itoa(depth), b.path(p)}, &stdout); err != nil {
+		return nil, err
+	}
+
+	var usage []DiskUsage
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		kb, dir, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(kb, 10, 64)
+		if err != nil {
+			continue
+		}
+		usage = append(usage, DiskUsage{Path: b.volumePath(dir), Size: size * 1024})
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Size > usage[j].Size })
+	return usage, nil
+}
+
+// Find returns the paths below p that match opts, in the order find visits
+// them. It doesn't descend into other file systems, such as /proc or the
+// volumes mounted in a session.
+func (b *Browser) Find(ctx context.Context, p string, opts FindOptions) ([]string, error) {
+	cmd := []string{"find", b.path(p), "-xdev"}
+	if opts.MaxDepth > 0 {
+		cmd = append(cmd, "-maxdepth", strconv.Itoa(opts.MaxDepth))
+	}
+	if opts.Type != "" {
+		cmd = append(cmd, "-type", opts.Type)
+	}
+	if opts.Name != "" {
+		cmd = append(cmd, "-name", opts.Name)
+	}
+
+	var stdout bytes.Buffer
+	if err := b.exec(ctx, cmd, &stdout); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line != "" {
+			paths = append(paths, b.volumePath(line))
+		}
+	}
+	return paths, nil
+}
+
+// path returns the path in the pod of path p in the volume
+func (b *Browser) path(p string) string {
+	return path.Join(b.root, path.Clean("/"+p))
+}
+
+// volumePath returns the path in the volume of path p in the pod
+func (b *Browser) volumePath(p string) string {
+	if b.root == "/" {
+		return p
+	}
+	if p = strings.TrimPrefix(p, b.root); p == "" {
+		return "/"
+	}
+	return p
+}
+
+// exec runs a command in the pod, writing its stdout to w. Errors include
+// the command's stderr, with pod paths shown as volume paths.
+func (b *Browser) exec(ctx context.Context, cmd []string, w io.Writer) error {
+	var stderr bytes.Buffer
+	if err := execInPod(ctx, b.c, b.podName, cmd, nil, w, &stderr); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return err
+		}
+		if b.root != "/" {
+			msg = strings.ReplaceAll(msg, b.root+"/", "/")
+			msg = strings.ReplaceAll(msg, b.root, "/")
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// directPort is the port the source copy pod listens on for direct transfers
+const directPort = "7878"
+
+// The destination is measured with du to show the progress of a direct
+// transfer: at most every directProgressInterval, and less often when du
+// takes long, so that it takes at most 1/directProgressLoad of the time
+const (
+	directProgressInterval = 5 * time.Second
+	directProgressLoad     = 10
+)
+
+// errNoPodNetwork is returned by directStream when the destination pod can't
+// reach the source pod, so that the stream has to be relayed instead
+var errNoPodNetwork = errors.New("pods can't reach each other")
+
+// Files used by the listener in the source copy pod
+const (
+	serveScriptPath = "/tmp/sgs-serve.sh"
+	serveErrPath    = "/tmp/sgs-serve.err"
+	serveRCPath     = "/tmp/sgs-serve.rc"
+	serveSumPath    = "/tmp/sgs-serve.sum"
+)
+
+// serveScript returns the script the source pod runs for each connection.
+// It sends the tar stream of dir only to clients that first send token, and
+// records the exit code (and the stream hash, with verify) for sgs to check.
+func serveScript(dir, token string, opts transferOptions) string {
+	archive := "tar cf - -C " + shellQuote(dir) + " ."
+	if c := compressCommand(opts.compress); c != "" {
+		archive += " | " + c
+	}
+	hashing := ""
+	if opts.verify {
+		hashing = "rm -f /tmp/sgs-serve.fifo; mkfifo /tmp/sgs-serve.fifo || exit 1\n" +
+			"sha256sum < /tmp/sgs-serve.fifo | cut -d' ' -f1 > " + serveSumPath + " &\n"
+		archive += " | tee /tmp/sgs-serve.fifo"
+	}
+	return "#!/bin/sh\n" +
+		"exec 2> " + serveErrPath + "\n" +
+		`read -r token && [ "$token" = ` + shellQuote(token) + " ] || exit 1\n" +
+		"set -o pipefail\n" +
+		hashing +
+		archive + "\n" +
+		"rc=$?; wait; echo $rc > " + serveRCPath + "\n"
+}
+
+// directStream streams the contents of srcDir in srcPod into dstDir in dstPod
+// over the pod network. The source pod serves the stream to the holder of a
+// random token and the destination pod pulls it, so the data never passes
+// through the client. Returns an error wrapping errNoPodNetwork if the
+// destination can't connect, before anything is transferred.
+func directStream(ctx context.Context, c *client.Client, srcPod, srcDir, dstPod, dstDir string, opts transferOptions) error {
+	podIP, err := client.RetryWithContext(ctx, func() (string, error) {
+		p, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, srcPod, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return p.Status.PodIP, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get source pod: %w", err)
+	}
+	if podIP == "" {
+		return fmt.Errorf("%w: source pod has no IP", errNoPodNetwork)
+	}
+
+	// Start the listener in the background of the source pod
+	token := rand.Text()
+	var stderr bytes.Buffer
+	listen := `cat > "$1" && chmod 700 "$1" && { nohup nc -ll -p "$2" -e "$1" < /dev/null > /dev/null 2>&1 & }`
+	if err := execInPod(ctx, c, srcPod, []string{"sh", "-c", listen, "sh", serveScriptPath, directPort},
+		strings.NewReader(serveScript(srcDir, token, opts)), io.Discard, &stderr); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: failed to start listener: %v: %s", errNoPodNetwork, err, strings.TrimSpace(stderr.String()))
+	}
+	defer func() {
+		_ = execInPod(context.Background(), c, srcPod, []string{"pkill", "-f", serveScriptPath}, nil, io.Discard, io.Discard)
+	}()
+
+	// Check that the destination can connect; the listener may take a moment to start
+	probe := `for i in 1 2 3 4 5; do nc -z -w 3 "$1" "$2" && exit 0; sleep 1; done; exit 1`
+	if err := execInPod(ctx, c, dstPod, []string{"sh", "-c", probe, "sh", podIP, directPort}, nil, io.Discard, io.Discard); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: cannot connect to %s:%s", errNoPodNetwork, podIP, directPort)
+	}
+
+	fmt.Println("  Streaming data directly between pods...")
+
+	// The stream never passes through sgs, so progress is measured on the destination
+	total := remoteSize(ctx, c, srcPod, srcDir)
+	base := remoteSize(ctx, c, dstPod, dstDir)
+	progress := newTransferProgress(io.Discard, total)
+	pollCtx, stopPoll := context.WithCancel(ctx)
+	pollDone := make(chan struct{})
+	go func() {
+		defer close(pollDone)
+		interval := directProgressInterval
+		for {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-time.After(interval):
+				start := time.Now()
+				if size := remoteSize(pollCtx, c, dstPod, dstDir); size > 0 {
+					progress.update(max(size-base, 0))
+				}
+				interval = max(directProgressInterval, directProgressLoad*time.Since(start))
+			}
+		}
+	}()
+
+	// Destination: connect, send the token and extract what the source sends back
+	pull := `set -o pipefail; echo "$2" | nc -w 60 "$3" "$4" | ( ` + extractScript(opts.compress, opts.verify) + " )"
+	var dstStdout, dstStderr bytes.Buffer
+	err = execInPod(ctx, c, dstPod, []string{"sh", "-c", pull, "sh", dstDir, token, podIP, directPort}, nil, &dstStdout, &dstStderr)
+	stopPoll()
+	<-pollDone
+	if err != nil {
+		if dstStderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, dstStderr.String())
+		}
+		return fmt.Errorf("copy stream failed: %w", err)
+	}
+
+	// The source records how its side of the stream went
+	rc, err := readPodFile(ctx, c, srcPod, serveRCPath)
+	if err != nil {
+		return fmt.Errorf("copy stream failed: source did not finish sending: %w", err)
+	}
+	if rc != "0" {
+		msg, _ := readPodFile(ctx, c, srcPod, serveErrPath)
+		return fmt.Errorf("copy stream failed: source exited with code %s: %s", rc, msg)
+	}
+
+	if size := remoteSize(ctx, c, dstPod, dstDir); size > 0 {
+		progress.update(max(size-base, 0))
+	}
+	fmt.Printf("\r  %-72s\n", progress.summary())
+
+	if opts.verify {
+		sent, err := readPodFile(ctx, c, srcPod, serveSumPath)
+		if err != nil {
+			return fmt.Errorf("failed to read source stream checksum: %w", err)
+		}
+		return checkStreamHash(sent, dstStdout.String())
+	}
+	return nil
+}
+
+// readPodFile returns the trimmed contents of a small file in a pod
+func readPodFile(ctx context.Context, c *client.Client, podName, path string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	if err := execInPod(ctx, c, podName, []string{"cat", path}, nil, &stdout, &stderr); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,223 @@
+package volume
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// dockerfileProfile is where the ENV and WORKDIR of a Dockerfile are kept in
+// the volume, as sessions don't run the setup script
+const dockerfileProfile = "/etc/profile.d/sgs-dockerfile.sh"
+
+// shellQuoteFunc defines sgs_quote, which single-quotes its argument
+const shellQuoteFunc = `sgs_quote() { printf "'%s'" "$(printf '%s' "$1" | sed "s/'/'\\\\''/g")"; }`
+
+// SetupPlan is an OS image and a setup script to run on top of it
+type SetupPlan struct {
+	Image    string
+	Script   string
+	Warnings []string // Instructions that were ignored
+}
+
+// ParseDockerfile translates a single-stage Dockerfile into a setup plan: the
+// FROM image and a shell script that runs its RUN instructions in order,
+// with the ENV, ARG and WORKDIR instructions before them applied. Like a
+// build, the script stops at the first RUN that fails. COPY and ADD are not
+// supported, as the build context is not available in the cluster.
+// Instructions that only describe the image (CMD, EXPOSE, ...) are ignored
+// with a warning. The final ENV values and WORKDIR are written to
+// dockerfileProfile for login shells.
+func ParseDockerfile(data string) (*SetupPlan, error) {
+	plan := &SetupPlan{}
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\nset -e\n")
+
+	steps := 0
+	var envNames []string // ENV variables to keep, in order of first use
+	workdir := false
+	for _, inst := range dockerfileInstructions(data) {
+		keyword, args, _ := strings.Cut(inst.text, " ")
+		keyword = strings.ToUpper(keyword)
+		args = strings.TrimSpace(args)
+
+		switch keyword {
+		case "FROM":
+			if plan.Image != "" {
+				return nil, fmt.Errorf("line %d: multi-stage builds are not supported", inst.line)
+			}
+			fields := strings.Fields(args)
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:] // e.g. --platform
+			}
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: FROM needs an image", inst.line)
+			}
+			plan.Image = fields[0]
+			continue
+		}
+		if plan.Image == "" {
+			if keyword == "ARG" {
+				continue // Only used by FROM, which doesn't support them here
+			}
+			return nil, fmt.Errorf("line %d: %s before FROM", inst.line, keyword)
+		}
+
+		switch keyword {
+		case "RUN":
+			command, err := dockerfileCommand(args)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", inst.line, err)
+			}
+			steps++
+			fmt.Fprintf(&script, "echo %s\n", shellQuote(fmt.Sprintf("==> Step %d: RUN %s", steps, oneLine(args))))
+			fmt.Fprintf(&script, "/bin/sh -c %s\n", shellQuote(command))
+		case "ENV":
+			for _, kv := range dockerfileKeyValues(args) {
+				fmt.Fprintf(&script, "export %s\n", kv)
+				if name, _, _ := strings.Cut(kv, "="); !slices.Contains(envNames, name) {
+					envNames = append(envNames, name)
+				}
+			}
+		case "ARG":
+			for _, kv := range dockerfileKeyValues(args) {
+				// Build arguments are in the environment of RUN, like ENV
+				if strings.Contains(kv, "=") {
+					fmt.Fprintf(&script, "export %s\n", kv)
+				}
+			}
+		case "WORKDIR":
+			// Double quotes keep variables from ENV and ARG working
+			fmt.Fprintf(&script, "mkdir -p \"%s\" && cd \"%s\"\n", args, args)
+			workdir = true
+		case "COPY", "ADD":
+			return nil, fmt.Errorf("line %d: %s is not supported; copy files into the volume with 'sgs cp' once it is created", inst.line, keyword)
+		default:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("line %d: %s ignored", inst.line, keyword))
+		}
+	}
+
+	if plan.Image == "" {
+		return nil, fmt.Errorf("no FROM instruction")
+	}
+	if steps == 0 {
+		return nil, fmt.Errorf("no RUN instructions")
+	}
+	if len(envNames) > 0 || workdir {
+		// Values are expanded now, as in the image a build would make
+		script.WriteString(shellQuoteFunc + "\n")
+		script.WriteString("mkdir -p /etc/profile.d\n{\n")
+		script.WriteString("echo '# ENV and WORKDIR of the Dockerfile this volume was set up from'\n")
+		for _, name := range envNames {
+			fmt.Fprintf(&script, "echo \"export %s=$(sgs_quote \"$%s\")\"\n", name, name)
+		}
+		if workdir {
+			script.WriteString("echo \"cd $(sgs_quote \"$PWD\")\"\n")
+		}
+		fmt.Fprintf(&script, "} > %s\n", dockerfileProfile)
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("ENV and WORKDIR are saved to %s, which login shells read; in other shells, source it", dockerfileProfile))
+	}
+	plan.Script = script.String()
+	return plan, nil
+}
+
+// dockerfileInstruction is an instruction with its continuation lines joined
+type dockerfileInstruction struct {
+	line int // Line the instruction starts on
+	text string
+}
+
+// dockerfileInstructions splits a Dockerfile into instructions, joining lines
+// that end with a backslash and dropping comments and blank lines
+func dockerfileInstructions(data string) []dockerfileInstruction {
+	var instructions []dockerfileInstruction
+	var current strings.Builder
+	start := 0
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue // Comments and blank lines may appear inside continuations
+		}
+		if current.Len() == 0 {
+			start = i + 1
+		}
+		if body, ok := strings.CutSuffix(trimmed, `\`); ok {
+			current.WriteString(body)
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(trimmed)
+		instructions = append(instructions, dockerfileInstruction{line: start, text: strings.TrimSpace(current.String())})
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		instructions = append(instructions, dockerfileInstruction{line: start, text: strings.TrimSpace(current.String())})
+	}
+	return instructions
+}
+
+// dockerfileCommand returns the shell command of a RUN instruction in shell
+// form or exec form (a JSON array)
+func dockerfileCommand(args string) (string, error) {
+	if !strings.HasPrefix(args, "[") {
+		if strings.HasPrefix(args, "--") {
+			return "", fmt.Errorf("RUN options such as %s are not supported", strings.Fields(args)[0])
+		}
+		return args, nil
+	}
+	var argv []string
+	if err := json.Unmarshal([]byte(args), &argv); err != nil || len(argv) == 0 {
+		return "", fmt.Errorf("invalid RUN command %s", args)
+	}
+	quoted := make([]string, len(argv))
+	for i, a := range argv {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+// dockerfileKeyValues splits the arguments of ENV or ARG into shell
+// assignments. The legacy "ENV key value" form is converted to key=value,
+// with the value double-quoted so that variables in it expand as in a build.
+func dockerfileKeyValues(args string) []string {
+	key, value, found := strings.Cut(args, " ")
+	if !strings.Contains(key, "=") && found {
+		return []string{key + "=" + doubleQuote(strings.TrimSpace(value))}
+	}
+	// key=value pairs keep their shell quoting; split on unquoted spaces
+	var pairs []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range args {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t':
+			if current.Len() > 0 {
+				pairs = append(pairs, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		pairs = append(pairs, current.String())
+	}
+	return pairs
+}
+
+// doubleQuote double-quotes s for the shell, leaving $ to expand
+func doubleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(s) + `"`
+}
+
+// oneLine collapses runs of whitespace in s, for showing a command on one line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
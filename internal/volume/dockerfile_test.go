@@ -0,0 +1,131 @@
+package volume
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDockerfileKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want []string
+	}{
+		{"pair", "A=1", []string{"A=1"}},
+		{"pairs", "A=1 B=2", []string{"A=1", "B=2"}},
+		{"quoted pair", `A="x y" B='$z'`, []string{`A="x y"`, `B='$z'`}},
+		{"tabs", "A=1\tB=2", []string{"A=1", "B=2"}},
+		{"arg without default", "VERSION", []string{"VERSION"}},
+		{"legacy", "PATH /opt/conda/bin:$PATH", []string{`PATH="/opt/conda/bin:$PATH"`}},
+		{"legacy with spaces", "GREETING hello  world ", []string{`GREETING="hello  world"`}},
+		{"legacy with quotes", `MSG say "hi" to \ and ` + "`cmd`", []string{`MSG="say \"hi\" to \\ and \` + "`cmd\\`" + `"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dockerfileKeyValues(tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("dockerfileKeyValues(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDockerfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		image      string
+		script     []string // Lines the script must contain, in order
+		warnings   int
+		err        string // Substring of the error, if any
+	}{
+		{
+			name:       "run",
+			dockerfile: "FROM ubuntu:22.04\nRUN apt-get update\n",
+			image:      "ubuntu:22.04",
+			script:     []string{"/bin/sh -c 'apt-get update'"},
+		},
+		{
+			name:       "platform flag, lowercase and comments",
+			dockerfile: "# base\nfrom --platform=linux/amd64 ubuntu\n\nrun true\n",
+			image:      "ubuntu",
+			script:     []string{"/bin/sh -c 'true'"},
+		},
+		{
+			name:       "continuation lines",
+			dockerfile: "FROM ubuntu\nRUN apt-get update && \\\n    # comment inside\n    apt-get install -y git\n",
+			image:      "ubuntu",
+			script:     []string{"/bin/sh -c 'apt-get update &&  apt-get install -y git'"},
+		},
+		{
+			name:       "exec form",
+			dockerfile: "FROM ubuntu\nRUN [\"echo\", \"it's\"]\n",
+			image:      "ubuntu",
+			script:     []string{`/bin/sh -c ''\''echo'\'' '\''it'\''\'\'''\''s'\'''`},
+		},
+		{
+			name:       "env and workdir",
+			dockerfile: "FROM ubuntu\nENV PATH /opt/conda/bin:$PATH\nWORKDIR /work\nRUN conda --version\n",
+			image:      "ubuntu",
+			script: []string{
+				`export PATH="/opt/conda/bin:$PATH"`,
+				`mkdir -p "/work" && cd "/work"`,
+				"/bin/sh -c 'conda --version'",
+				`echo "export PATH=$(sgs_quote "$PATH")"`,
+				`echo "cd $(sgs_quote "$PWD")"`,
+				"} > " + dockerfileProfile,
+			},
+			warnings: 1,
+		},
+		{
+			name:       "arg before from",
+			dockerfile: "ARG BASE=ubuntu\nFROM ubuntu\nARG JOBS=4\nARG NAME\nRUN make -j$JOBS\n",
+			image:      "ubuntu",
+			script:     []string{"export JOBS=4", "/bin/sh -c 'make -j$JOBS'"},
+		},
+		{
+			name:       "ignored instructions",
+			dockerfile: "FROM ubuntu\nRUN true\nEXPOSE 8080\nCMD [\"bash\"]\n",
+			image:      "ubuntu",
+			warnings:   2,
+		},
+		{name: "no from", dockerfile: "RUN true\n", err: "RUN before FROM"},
+		{name: "empty", dockerfile: "# nothing\n", err: "no FROM instruction"},
+		{name: "no run", dockerfile: "FROM ubuntu\nENV A=1\n", err: "no RUN instructions"},
+		{name: "multi-stage", dockerfile: "FROM golang AS build\nRUN true\nFROM ubuntu\n", err: "line 3: multi-stage"},
+		{name: "copy", dockerfile: "FROM ubuntu\nCOPY . /app\n", err: "line 2: COPY is not supported"},
+		{name: "run options", dockerfile: "FROM ubuntu\nRUN --mount=type=cache,target=/root/.cache true\n", err: "RUN options"},
+		{name: "invalid exec form", dockerfile: "FROM ubuntu\nRUN [\"echo\"\n", err: "invalid RUN command"},
+		{name: "from without image", dockerfile: "FROM --platform=linux/amd64\n", err: "FROM needs an image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := ParseDockerfile(tt.dockerfile)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseDockerfile() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDockerfile() error = %v", err)
+			}
+			if plan.Image != tt.image {
+				t.Errorf("Image = %q, want %q", plan.Image, tt.image)
+			}
+			if len(plan.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %q, want %d", plan.Warnings, tt.warnings)
+			}
+			lines := strings.Split(plan.Script, "\n")
+			i := 0
+			for _, want := range tt.script {
+				for i < len(lines) && lines[i] != want {
+					i++
+				}
+				if i == len(lines) {
+					t.Fatalf("script is missing %q (in order):\n%s", want, plan.Script)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package volume
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsLocalPath reports whether a copy argument refers to the local filesystem.
+// Local paths must be absolute or start with ".", "..", or "~" (e.g. ./results),
+// so that they can't be mistaken for <node>/<volume>.
+func IsLocalPath(arg string) bool {
+	if filepath.IsAbs(arg) {
+		return true
+	}
+	for _, prefix := range []string{".", "~"} {
+		if arg == prefix || strings.HasPrefix(arg, prefix+"/") || strings.HasPrefix(arg, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return arg == ".." || strings.HasPrefix(arg, "../") || strings.HasPrefix(arg, ".."+string(filepath.Separator))
+}
+
+// expandLocalPath expands a leading "~" to the home directory
+func expandLocalPath(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, p[1:]), nil
+}
+
+// Upload copies a local file or directory into a volume. If dstPath is an
+// existing directory (or ends with "/"), the source is copied into it;
+// otherwise it is copied to dstPath. Permissions and modification times are kept.
+func Upload(ctx context.Context, c *client.Client, localPath, nodeName, volumeName, dstPath string) error {
+	localPath, err := expandLocalPath(localPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return fmt.Errorf("source %s: %w", localPath, errors.Unwrap(err))
+	}
+
+	podName, isOS, err := checkCopyVolume(ctx, c, nodeName, volumeName, "destination")
+	if err != nil {
+		return err
+	}
+	podName = "copy-up-" + podName
+	stop, err := startCopyPod(ctx, c, podName, nodeName, volumeName, false, isOS)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// Work out the directory to extract into and the name of the copy
+	target := path.Join("/data", path.Clean("/"+dstPath))
+	dir, name := path.Dir(target), path.Base(target)
+	isDir, err := remoteIsDir(ctx, c, podName, target)
+	if err != nil {
+		return err
+	}
+	if isDir || strings.HasSuffix(dstPath, "/") {
+		dir, name = target, filepath.Base(localPath)
+	}
+
+	// Stream a tar of the local path into the pod
+	pr, pw := io.Pipe()
+	progress := newTransferProgress(pw, 0)
+
+	var stderr bytes.Buffer
+	errChan := make(chan error, 2)
+
+	go func() {
+		err := writeLocalTar(progress, localPath, name)
+		pw.CloseWithError(err)
+		errChan <- err
+	}()
+
+	go func() {
+		// -o: files are owned by the volume's user, not the local uid
+		err := execInPod(ctx, c, podName, []string{"sh", "-c", `mkdir -p "$1" && tar -x -o -f - -C "$1"`, "sh", dir}, pr, nil, &stderr)
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		pr.CloseWithError(err)
+		errChan <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errChan; err != nil {
+			fmt.Println()
+			return fmt.Errorf("upload failed: %w", err)
+		}
+	}
+
+	fmt.Printf("\r  %-72s\n", progress.summary())
+	return nil
+}
+
+// Download copies a file or directory from a volume to the local filesystem.
+// If localPath is an existing directory (or ends with a separator), the source
+// is copied into it; otherwise it is copied to localPath. Permissions and
+// modification times are kept.
+func Download(ctx context.Context, c *client.Client, nodeName, volumeName, srcPath, localPath string) error {
+	localPath, err := expandLocalPath(localPath)
+	if err != nil {
+		return err
+	}
+
+	srcPath = strings.TrimPrefix(path.Clean("/"+srcPath), "/")
+	srcName := path.Base(srcPath)
+	if srcPath == "" {
+		srcName = volumeName // Whole volume
+	}
+
+	// Work out where the copy goes locally
+	target := localPath
+	if info, err := os.Stat(localPath); (err == nil && info.IsDir()) || strings.HasSuffix(localPath, string(filepath.Separator)) || strings.HasSuffix(localPath, "/") {
+		target = filepath.Join(localPath, srcName)
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	parent := filepath.Dir(target)
+	if info, err := os.Stat(parent); err != nil || !info.IsDir() {
+		return fmt.Errorf("destination directory %s does not exist", parent)
+	}
+
+	podName, isOS, err := checkCopyVolume(ctx, c, nodeName, volumeName, "source")
+	if err != nil {
+		return err
+	}
+	podName = "copy-down-" + podName
+	stop, err := startCopyPod(ctx, c, podName, nodeName, volumeName, true, isOS)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// tar the source path itself, so that a single file can be copied too
+	tarCmd := []string{"tar", "cf", "-", "-C", "/data", "."}
+	if srcPath != "" {
+		tarCmd = []string{"tar", "cf", "-", "-C", path.Dir("/data/" + srcPath), "./" + path.Base(srcPath)}
+	}
+
+	pr, pw := io.Pipe()
+	progress := newTransferProgress(pw, 0)
+
+	var stderr bytes.Buffer
+	errChan := make(chan error, 2)
+
+	go func() {
+		err := execInPod(ctx, c, podName, tarCmd, nil, progress, &stderr)
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		pw.CloseWithError(err)
+		errChan <- err
+	}()
+
+	go func() {
+		err := extractLocalTar(pr, parent, filepath.Base(target), srcPath != "")
+		pr.CloseWithError(err)
+		errChan <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errChan; err != nil {
+			fmt.Println()
+			return fmt.Errorf("download failed: %w", err)
+		}
+	}
+
+	fmt.Printf("\r  %-72s\n", progress.summary())
+	return nil
+}
+
+// checkCopyVolume checks that a volume exists and has no active session.
+// It returns the PVC name and whether it is an OS volume.
+func checkCopyVolume(ctx context.Context, c *client.Client, nodeName, volumeName, role string) (string, bool, error) {
+	info, err := Get(ctx, c, nodeName, volumeName)
+	if err != nil {
+		return "", false, fmt.Errorf("%s volume %s/%s not found", role, nodeName, volumeName)
+	}
+
+	mode, err := GetSessionMode(ctx, c, nodeName, volumeName)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check %s session: %w", role, err)
+	}
+	if mode != "" {
+		return "", false, fmt.Errorf("%s volume %s/%s has an active session, please delete it first", role, nodeName, volumeName)
+	}
+
+	return PVCName(nodeName, volumeName), info.IsOSVolume, nil
+}
+
+// startCopyPod starts a copy pod for streaming transfers and waits for it
+// to run. The returned function deletes the pod.
+func startCopyPod(ctx context.Context, c *client.Client, podName, nodeName, volumeName string, readOnly, isOS bool) (func(), error) {
+	pod := createCopyPod(podName, nodeName, PVCName(nodeName, volumeName), c.Namespace, readOnly, isOS)
+	if _, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create copy pod: %w", err)
+	}
+
+	// Register cleanup for interrupt handling
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "  Cleaning up copy pod...")
+		if err := c.Clientset.CoreV1().Pods(c.Namespace).Delete(cleanupCtx, podName, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+	stop := func() {
+		cleanup.Unregister()
+		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+	}
+
+	fmt.Print("  Waiting for copy pod to start...")
+	if err := waitForPodRunning(ctx, c, podName, 5*time.Minute); err != nil {
+		fmt.Println(" failed")
+		stop()
+		return nil, fmt.Errorf("copy pod failed to start: %w", err)
+	}
+	fmt.Println(" done")
+	return stop, nil
+}
+
+// remoteIsDir reports whether p is a directory in a copy pod
+func remoteIsDir(ctx context.Context, c *client.Client, podName, p string) (bool, error) {
+	var stdout, stderr bytes.Buffer
+	err := execInPod(ctx, c, podName, []string{"sh", "-c", `if [ -d "$1" ]; then echo dir; fi`, "sh", p}, nil, &stdout, &stderr)
+	if err != nil {
+		return false, fmt.Errorf("failed to check destination: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()) == "dir", nil
+}
+
+// writeLocalTar writes a tar archive of a local file or directory to w,
+// with entries named name and name/<relative path>
+func writeLocalTar(w io.Writer, localPath, name string) error {
+	// Follow a symbolic link given as the source
+	localPath, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	err = filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		return writeTarEntry(tw, p, path.Join(name, filepath.ToSlash(rel)), info)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// writeTarEntry writes the local file p with the given info to tw as name.
+// Symbolic links are archived as links; sockets and devices are skipped.
+func writeTarEntry(tw *tar.Writer, p, name string, info fs.FileInfo) error {
+	link := ""
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		var err error
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	case !info.Mode().IsRegular() && !info.IsDir():
+		fmt.Fprintf(os.Stderr, "\r  Skipping %s (not a regular file)\n", p)
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	// GNU long names are understood by both GNU and busybox tar
+	hdr.Format = tar.FormatGNU
+	hdr.ModTime = hdr.ModTime.Truncate(time.Second)
+	hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if info.Mode().IsRegular() {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(tw, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractLocalTar extracts a tar archive into dir, renaming its top-level entry
+// to name (if renameTop) or placing all entries under name. Entries can't
+// escape dir, even through symbolic links in the archive.
+func extractLocalTar(r io.Reader, dir, name string, renameTop bool) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	type dirMode struct {
+		name    string
+		mode    fs.FileMode
+		modTime time.Time
+	}
+	var dirs []dirMode
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Map the entry name to a path below dir
+		entry := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if renameTop {
+			if _, rest, found := strings.Cut(entry, "/"); found {
+				entry = rest
+			} else {
+				entry = "."
+			}
+		}
+		target := filepath.Join(name, filepath.FromSlash(entry))
+		mode := hdr.FileInfo().Mode()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			// Set directory permissions last, so read-only directories can be filled
+			dirs = append(dirs, dirMode{target, mode.Perm(), hdr.ModTime})
+		case tar.TypeReg:
+			if err := root.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := root.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			if err := root.Chmod(target, mode.Perm()); err != nil {
+				return err
+			}
+			_ = root.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		case tar.TypeSymlink:
+			if err := root.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			_ = root.Remove(target)
+			if err := root.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			linkName := path.Clean(strings.TrimPrefix(hdr.Linkname, "./"))
+			if renameTop {
+				if _, rest, found := strings.Cut(linkName, "/"); found {
+					linkName = rest
+				} else {
+					linkName = "."
+				}
+			}
+			_ = root.Remove(target)
+			if err := root.Link(filepath.Join(name, filepath.FromSlash(linkName)), target); err != nil {
+				return err
+			}
+		default:
+			// Devices, FIFOs, etc. can't be meaningfully copied to the local machine
+			fmt.Fprintf(os.Stderr, "\r  Skipping %s (not a regular file)\n", hdr.Name)
+		}
+	}
+
+	// Innermost directories first, so parents stay writable until their children are done
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := root.Chmod(dirs[i].name, dirs[i].mode); err != nil {
+			return err
+		}
+		_ = root.Chtimes(dirs[i].name, dirs[i].modTime, dirs[i].modTime)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Move renames a volume or moves it to another node. Volumes are named after
+// their node (see pvcName), so the volume is copied to the new name with
+// verification, keeping its OS image, and the source is deleted only once
+// the copy is complete. If anything fails before then, the copy is deleted
+// and the source is left as it was. The path fields of opts are ignored.
+func Move(ctx context.Context, c *client.Client, opts CopyOptions) error {
+	if opts.SrcNode == opts.DstNode && opts.SrcVolume == opts.DstVolume {
+		return fmt.Errorf("source and destination are the same volume")
+	}
+	srcPath := FormatVolumePath(opts.SrcNode, opts.SrcVolume)
+	dstPath := FormatVolumePath(opts.DstNode, opts.DstVolume)
+
+	opts.SrcPath, opts.DstPath = "", ""
+	opts.Verify = true
+	if err := Copy(ctx, c, opts); err != nil {
+		return err
+	}
+	// Copy returns once an interrupt has been cleaned up; the source must stay
+	if cleanup.WasInterrupted() || ctx.Err() != nil {
+		return fmt.Errorf("move of %s interrupted, source volume kept", srcPath)
+	}
+
+	// Until the source is deleted, roll back by deleting the copy
+	dstPVCName := PVCName(opts.DstNode, opts.DstVolume)
+	rollback := func(ctx context.Context) error {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(ctx, dstPVCName, metav1.DeleteOptions{})
+	}
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "Rolling back, deleting destination volume...")
+		if err := rollback(cleanupCtx); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+	fail := func(err error) error {
+		cleanup.Unregister()
+		fmt.Printf("Rolling back, deleting destination volume %s...", dstPath)
+		if rbErr := rollback(context.Background()); rbErr != nil {
+			fmt.Println(" failed")
+			return fmt.Errorf("%w (rollback failed, delete %s manually: %v)", err, dstPath, rbErr)
+		}
+		fmt.Println(" done")
+		return err
+	}
+
+	// A session started during the copy could have changed the source
+	mode, err := GetSessionMode(ctx, c, opts.SrcNode, opts.SrcVolume)
+	if err != nil {
+		return fail(fmt.Errorf("failed to check source session: %w", err))
+	}
+	if mode != "" {
+		return fail(fmt.Errorf("a session was started on %s during the move, source volume kept", srcPath))
+	}
+
+	fmt.Printf("Deleting source volume %s...\n", srcPath)
+	if err := Delete(ctx, c, opts.SrcNode, opts.SrcVolume); err != nil {
+		return fail(fmt.Errorf("failed to delete source volume: %w", err))
+	}
+	cleanup.Unregister()
+
+	// Snapshots stay with the name of the volume they were taken of
+	if snapshots, err := ListSnapshots(ctx, c); err == nil {
+		count := 0
+		for _, s := range snapshots {
+			if s.NodeName == opts.SrcNode && s.VolumeName == opts.SrcVolume {
+				count++
+			}
+		}
+		if count > 0 {
+			fmt.Printf("Note: %d snapshots of %s were not moved; see 'sgs snapshot list %s'\n", count, srcPath, srcPath)
+		}
+	}
+
+	fmt.Printf("Successfully moved %s to %s\n", srcPath, dstPath)
+	return nil
+}
@@ -0,0 +1,121 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// maxPortForwardRetries is the number of consecutive reconnect attempts
+// before port forwarding gives up
+const maxPortForwardRetries = 5
+
+// portForwardDialer returns a dialer for the portforward subresource of a pod
+func portForwardDialer(c *client.Client, podName string) (httpstream.Dialer, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create round tripper: %w", err)
+	}
+
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(c.Namespace).
+		SubResource("portforward")
+	return spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL()), nil
+}
+
+// PortForward forwards local ports to a session pod until ctx is cancelled.
+// Ports use the "<local>:<remote>" or "<port>" format. When the connection
+// drops transiently, it reconnects as long as the pod is still running.
+func PortForward(ctx context.Context, c *client.Client, podName string, ports []string, out, errOut io.Writer) error {
+	dialer, err := portForwardDialer(c, podName)
+	if err != nil {
+		return err
+	}
+
+	// Close the listeners on interrupt so local ports are released before exit
+	var mu sync.Mutex
+	var current *portforward.PortForwarder
+	cleanup.Register(func(cleanupCtx context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		if current != nil {
+			current.Close()
+		}
+	})
+	defer cleanup.Unregister()
+
+	failures := 0
+	for {
+		stopCh := make(chan struct{})
+		readyCh := make(chan struct{})
+		fw, err := portforward.New(dialer, ports, stopCh, readyCh, out, errOut)
+		if err != nil {
+			return fmt.Errorf("invalid port: %w", err)
+		}
+		mu.Lock()
+		current = fw
+		mu.Unlock()
+
+		// Stop forwarding when the context is cancelled
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				close(stopCh)
+			case <-done:
+			}
+		}()
+
+		err = fw.ForwardPorts()
+		close(done)
+
+		if ctx.Err() != nil || err == nil {
+			return nil // Stopped by the user
+		}
+		if !errors.Is(err, portforward.ErrLostConnectionToPod) && !client.IsRetryableError(err) {
+			return err
+		}
+
+		// Reset the retry budget once a connection was established
+		select {
+		case <-readyCh:
+			failures = 0
+		default:
+		}
+		failures++
+		if failures > maxPortForwardRetries {
+			return fmt.Errorf("port forwarding failed after %d attempts: %w", maxPortForwardRetries, err)
+		}
+
+		// Don't reconnect to a session that has ended
+		pod, getErr := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if apierrors.IsNotFound(getErr) {
+			return fmt.Errorf("session no longer exists")
+		}
+		if getErr == nil && pod.Status.Phase != corev1.PodRunning {
+			return fmt.Errorf("session is no longer running (status: %s)", pod.Status.Phase)
+		}
+
+		fmt.Fprintf(errOut, "Connection lost (%v), reconnecting...\n", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(failures) * time.Second):
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrQuotaExceeded is returned when a session would exceed the workspace's
+// resource quota. It may succeed later, once other sessions end.
+var ErrQuotaExceeded = errors.New("workspace quota exceeded")
+
+// createSessionPod creates a session pod. The API server rejects pods over
+// the workspace quota outright, so this is the only place the reason shows up.
+func createSessionPod(ctx context.Context, c *client.Client, pod *corev1.Pod) error {
+	_, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	// e.g. pods "x" is forbidden: exceeded quota: ws-quota, requested: ..., used: ..., limited: ...
+	if apierrors.IsForbidden(err) {
+		if _, detail, found := strings.Cut(err.Error(), "exceeded quota: "); found {
+			return fmt.Errorf("%w (%s); delete sessions you no longer need, or check the quota with 'sgs describe workspace'", ErrQuotaExceeded, detail)
+		}
+	}
+	return client.FormatK8sError(err, "create", "session", c.Namespace)
+}
@@ -0,0 +1,296 @@
+package volume
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// rebaseImageTimeout bounds how long pods with the new image may take to
+// start, which includes pulling the image
+const rebaseImageTimeout = 10 * time.Minute
+
+// rebaseCheckMount is where the check pod mounts the volume. It must not be
+// the beacon mount, so that the pod sees the image itself as its root.
+const rebaseCheckMount = "/sgs-rebase"
+
+// rebaseCheckScript lists what in upper/ (the user's changes) may conflict
+// with the image the pod runs:
+//   - "dpkg": upper/ has its own package database, which hides the image's
+//   - "python <dir>": packages installed for a Python version the image lacks
+//   - "shadow <path>": a file that replaces a different file of the image
+//   - "hidden <path>": a file of the image that was deleted (a whiteout)
+//
+// Scratch directories are skipped, as their contents are not meant to last.
+const rebaseCheckScript = `cd ` + rebaseCheckMount + `/upper 2>/dev/null || exit 0
+[ -f var/lib/dpkg/status ] && echo dpkg
+for d in usr/lib/python3.* usr/local/lib/python3.*; do
+  [ -d "$d" ] || continue
+  v=${d##*/}
+  [ -e "/usr/bin/$v" ] || [ -e "/usr/local/bin/$v" ] || echo "python /$d"
+done
+find . \( -path ./tmp -o -path ./var/tmp -o -path ./var/cache -o -path ./var/log -o -path ./run \) -prune -o ! -type d -exec sh -c '
+for f; do
+  p=${f#.}
+  [ -e "$p" ] || [ -L "$p" ] || continue
+  if [ -c "$f" ]; then echo "hidden $p"; continue; fi
+  [ -f "$f" ] && [ -f "$p" ] && cmp -s "$f" "$p" && continue
+  echo "shadow $p"
+done' sh {} +`
+
+// RebaseOptions holds options for changing the image of an OS volume
+type RebaseOptions struct {
+	NodeName   string
+	VolumeName string
+	Image      string // New OS image
+	Backup     bool   // Take a snapshot of the volume first
+}
+
+// RebaseCheck lists the changes in an OS volume that may conflict with a
+// new image. The changes are kept by a rebase; they are only reported.
+type RebaseCheck struct {
+	OldImage string
+	NewImage string
+	// PackageDB is set if the volume has its own dpkg package database, which
+	// hides the packages of the new image from apt
+	PackageDB bool
+	// PythonDirs lists package directories of Python versions the new image lacks
+	PythonDirs []string
+	// Shadowed lists changed files that replace different files of the new image
+	Shadowed []string
+	// Hidden lists deleted files that exist in the new image and stay hidden
+	Hidden []string
+}
+
+// HasConflicts reports whether anything may conflict with the new image
+func (r *RebaseCheck) HasConflicts() bool {
+	return r.PackageDB || len(r.PythonDirs) > 0 || len(r.Shadowed) > 0 || len(r.Hidden) > 0
+}
+
+// rebaseTarget returns the PVC of an OS volume that can be rebased onto image
+func rebaseTarget(ctx context.Context, c *client.Client, opts RebaseOptions) (*corev1.PersistentVolumeClaim, error) {
+	name := PVCName(opts.NodeName, opts.VolumeName)
+	volumePath := FormatVolumePath(opts.NodeName, opts.VolumeName)
+
+	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("volume %s not found", volumePath)
+		}
+		return nil, client.FormatK8sError(err, "get", "volume", c.Namespace)
+	}
+
+	oldImage := pvc.Annotations[sgs.AnnotationOSImage]
+	if oldImage == "" {
+		return nil, fmt.Errorf("%s is a data volume; only OS volumes have an image", volumePath)
+	}
+	if oldImage == opts.Image {
+		return nil, fmt.Errorf("volume %s already uses %s", volumePath, opts.Image)
+	}
+
+	// The session's root filesystem is built on the current image
+	mode, err := GetSessionMode(ctx, c, opts.NodeName, opts.VolumeName)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		return nil, fmt.Errorf("volume %s has an active %s session; stop it first with 'sgs delete session %s'", volumePath, mode, volumePath)
+	}
+	return pvc, nil
+}
+
+// CheckRebase checks whether an OS volume can be rebased onto opts.Image and
+// lists the changes in the volume that may conflict with it. It pulls the
+// new image on the volume's node, so that a bad image fails before anything
+// is changed. The volume itself is not modified.
+func CheckRebase(ctx context.Context, c *client.Client, opts RebaseOptions) (*RebaseCheck, error) {
+	pvc, err := rebaseTarget(ctx, c, opts)
+	if err != nil {
+		return nil, err
+	}
+	check := &RebaseCheck{OldImage: pvc.Annotations[sgs.AnnotationOSImage], NewImage: opts.Image}
+
+	// A pod of the new image sees the image as its root and the volume beside it
+	pod := createCopyPod("rebase-check-"+pvc.Name, opts.NodeName, pvc.Name, c.Namespace, true, false)
+	pod.Spec.Containers[0].Image = opts.Image
+	pod.Spec.Containers[0].VolumeMounts[0].MountPath = rebaseCheckMount
+	podName := pod.Name
+	if _, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return nil, client.FormatK8sError(err, "create", "helper pod", c.Namespace)
+	}
+
+	// Register cleanup for interrupt handling
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "  Cleaning up helper pod...")
+		if err := c.Clientset.CoreV1().Pods(c.Namespace).Delete(cleanupCtx, podName, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+	defer func() {
+		cleanup.Unregister()
+		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+	}()
+
+	fmt.Printf("Pulling %s on %s...", opts.Image, opts.NodeName)
+	if err := waitForPodRunning(ctx, c, podName, rebaseImageTimeout); err != nil {
+		fmt.Println(" failed")
+		return nil, fmt.Errorf("failed to start the new image: %w", err)
+	}
+	fmt.Println(" done")
+
+	fmt.Print("Checking your changes against the new image...")
+	var stdout, stderr bytes.Buffer
+	if err := execInPod(ctx, c, podName, []string{"sh", "-c", rebaseCheckScript}, nil, &stdout, &stderr); err != nil {
+		fmt.Println(" failed")
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("conflict check failed: %s", msg)
+		}
+		return nil, fmt.Errorf("conflict check failed: %w", err)
+	}
+	fmt.Println(" done")
+
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		kind, p, _ := strings.Cut(scanner.Text(), " ")
+		switch kind {
+		case "dpkg":
+			check.PackageDB = true
+		case "python":
+			check.PythonDirs = append(check.PythonDirs, p)
+		case "shadow":
+			check.Shadowed = append(check.Shadowed, p)
+		case "hidden":
+			check.Hidden = append(check.Hidden, p)
+		}
+	}
+	return check, nil
+}
+
+// Rebase changes the image of an OS volume to opts.Image. The user's changes
+// in upper/ are kept and now lie on top of the new image; use CheckRebase
+// first to find the ones that may conflict with it. With opts.Backup, a
+// snapshot is taken first. The overlay is re-initialized by a binder pod of
+// the new image; if that fails, the volume keeps its old image.
+func Rebase(ctx context.Context, c *client.Client, opts RebaseOptions) error {
+	pvc, err := rebaseTarget(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+	oldImage := pvc.Annotations[sgs.AnnotationOSImage]
+	oldPrevious := pvc.Annotations[sgs.AnnotationPreviousOSImage]
+
+	if opts.Backup {
+		fmt.Println("Taking a snapshot before the rebase...")
+		snapshot, err := CreateSnapshot(ctx, c, SnapshotOptions{
+			NodeName:   opts.NodeName,
+			VolumeName: opts.VolumeName,
+			Name:       "pre-rebase-" + time.Now().Format("20060102-150405"),
+		})
+		if err != nil {
+			return fmt.Errorf("backup failed, volume not changed: %w", err)
+		}
+		if cleanup.WasInterrupted() || ctx.Err() != nil {
+			return fmt.Errorf("rebase interrupted, volume not changed")
+		}
+		fmt.Printf("Snapshot %q created; restore it with 'sgs snapshot restore %s %s'\n",
+			snapshot.Name, FormatVolumePath(opts.NodeName, opts.VolumeName), snapshot.Name)
+	}
+
+	// The annotation tells the runtime wrapper which image to use as the lower layer
+	if err := setOSImage(ctx, c, pvc.Name, opts.Image, oldImage); err != nil {
+		return err
+	}
+	revert := func(ctx context.Context) error {
+		return setOSImage(ctx, c, pvc.Name, oldImage, oldPrevious)
+	}
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "Restoring the previous image...")
+		if err := revert(cleanupCtx); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+	fail := func(err error) error {
+		cleanup.Unregister()
+		if rbErr := revert(context.Background()); rbErr != nil {
+			return fmt.Errorf("%w (restoring the previous image %s failed: %v)", err, oldImage, rbErr)
+		}
+		return fmt.Errorf("%w; the volume keeps %s", err, oldImage)
+	}
+
+	binderPod := createBinderPodSpec(pvc.Name, opts.NodeName, opts.Image, c.Namespace)
+	podName := binderPod.Name
+	if _, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, binderPod, metav1.CreateOptions{}); err != nil {
+		return fail(client.FormatK8sError(err, "create", "binder pod", c.Namespace))
+	}
+
+	// Register cleanup for binder pod
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "Cleaning up binder pod...")
+		if err := c.Clientset.CoreV1().Pods(c.Namespace).Delete(cleanupCtx, podName, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+
+	fmt.Printf("Initializing the volume with %s...", opts.Image)
+	err = waitForBinderPod(ctx, c, podName, rebaseImageTimeout)
+	if err != nil && cleanup.WasInterrupted() {
+		// The signal handler deletes the pod and restores the image
+		cleanup.WaitForCleanup()
+		return nil
+	}
+	cleanup.Unregister() // binder pod
+	_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+	if err != nil {
+		fmt.Println(" failed")
+		return fail(fmt.Errorf("volume initialization failed: %w", err))
+	}
+	cleanup.Unregister() // image annotation
+	fmt.Println(" done")
+	return nil
+}
+
+// setOSImage sets the OS image annotation of a PVC, recording previous as the
+// image it was rebased from (or removing that record if previous is empty)
+func setOSImage(ctx context.Context, c *client.Client, name, image, previous string) error {
+	var previousValue any
+	if previous != "" {
+		previousValue = previous
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{
+				sgs.AnnotationOSImage:         image,
+				sgs.AnnotationPreviousOSImage: previousValue,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
+	if _, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return client.FormatK8sError(err, "update", "volume", c.Namespace)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// resizeTimeout bounds how long Resize waits for the storage to be expanded
+const resizeTimeout = 5 * time.Minute
+
+// onlineResizeTimeout bounds how long Resize waits for a mounted file system
+// to grow before telling the user to restart the sessions using it
+const onlineResizeTimeout = time.Minute
+
+// ResizeOptions holds options for resizing a volume
+type ResizeOptions struct {
+	NodeName   string
+	VolumeName string
+	Size       string // New size, e.g. "200Gi"
+}
+
+// ResizeResult reports how far a resize got
+type ResizeResult struct {
+	OldSize string
+	NewSize string
+	// FileSystemPending is set if the file system grows the next time the volume is mounted
+	FileSystemPending bool
+	// RestartSessions lists the sessions (node/volume) to restart to use the new size
+	RestartSessions []string
+}
+
+// Resize grows a volume to opts.Size. Volumes can't be shrunk. Progress is written to out.
+func Resize(ctx context.Context, c *client.Client, opts ResizeOptions, out io.Writer) (*ResizeResult, error) {
+	newSize, err := resource.ParseQuantity(opts.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q (e.g. 200Gi)", opts.Size)
+	}
+
+	name := PVCName(opts.NodeName, opts.VolumeName)
+	volumePath := FormatVolumePath(opts.NodeName, opts.VolumeName)
+
+	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("volume %s not found", volumePath)
+		}
+		return nil, client.FormatK8sError(err, "get", "volume", c.Namespace)
+	}
+
+	oldSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch newSize.Cmp(oldSize) {
+	case -1:
+		return nil, fmt.Errorf("cannot shrink volume %s from %s to %s: volumes can only grow", volumePath, oldSize.String(), newSize.String())
+	case 0:
+		return nil, fmt.Errorf("volume %s is already %s", volumePath, oldSize.String())
+	}
+
+	// The storage of an unbound claim doesn't exist yet, and its spec can't be changed
+	if pvc.Status.Phase != corev1.ClaimBound {
+		return nil, fmt.Errorf("volume %s has not been provisioned yet (status: %s); it holds no data, so delete it and create it again with --size %s",
+			volumePath, pvc.Status.Phase, newSize.String())
+	}
+
+	if err := checkExpansionAllowed(ctx, c, pvc); err != nil {
+		return nil, err
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"resources": map[string]any{
+				"requests": map[string]string{string(corev1.ResourceStorage): newSize.String()},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patch: %w", err)
+	}
+	pvc, err = c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "resize", "volume", c.Namespace)
+	}
+	fmt.Fprintf(out, "Requested %s (was %s)\n", newSize.String(), oldSize.String())
+
+	result := &ResizeResult{OldSize: oldSize.String(), NewSize: newSize.String()}
+
+	users, err := sessionsUsingPVC(ctx, c, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitForResize(ctx, c, pvc, newSize, len(users) > 0, out); err != nil {
+		if err != errFileSystemResizePending {
+			return nil, err
+		}
+		if len(users) > 0 {
+			result.RestartSessions = users
+		} else {
+			result.FileSystemPending = true
+		}
+	}
+	return result, nil
+}
+
+// checkExpansionAllowed returns an error if the storage class of pvc can't expand volumes
+func checkExpansionAllowed(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim) error {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return fmt.Errorf("volume has no storage class and cannot be expanded")
+	}
+	scName := *pvc.Spec.StorageClassName
+
+	sc, err := client.RetryWithContext(ctx, func() (*storagev1.StorageClass, error) {
+		return c.Clientset.StorageV1().StorageClasses().Get(ctx, scName, metav1.GetOptions{})
+	})
+	if err != nil {
+		// Storage classes are cluster-scoped, so users may not be allowed to read
+		// them. The API server still rejects the resize if it isn't supported.
+		if isForbiddenError(err) {
+			return nil
+		}
+		return client.FormatK8sError(err, "get", "storage class", c.Namespace)
+	}
+
+	if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+		return fmt.Errorf("storage class %q does not support volume expansion; ask the administrators, or copy the data to a larger volume with 'sgs cp'", scName)
+	}
+	return nil
+}
+
+// errFileSystemResizePending means the storage was expanded, but the file
+// system grows only when the volume is mounted (again)
+var errFileSystemResizePending = fmt.Errorf("file system resize pending")
+
+// waitForResize watches pvc until its capacity reaches size. If the storage was
+// expanded but the file system was not, it returns errFileSystemResizePending:
+// immediately if the volume is not mounted, or after onlineResizeTimeout if it is.
+func waitForResize(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim, size resource.Quantity, mounted bool, out io.Writer) error {
+	waitCtx, cancel := context.WithTimeout(ctx, resizeTimeout)
+	defer cancel()
+
+	opts := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", pvc.Name).String(),
+	}
+	w, err := client.NewRetryWatcher(waitCtx, pvc.ResourceVersion, opts, c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Watch)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	var reported corev1.PersistentVolumeClaimConditionType
+	var onlineDeadline <-chan time.Time
+	for {
+		// Check the latest state: capacity is updated once the file system has grown
+		if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && capacity.Cmp(size) >= 0 {
+			fmt.Fprintf(out, "Volume resized to %s\n", capacity.String())
+			return nil
+		}
+		if status, ok := pvc.Status.AllocatedResourceStatuses[corev1.ResourceStorage]; ok && strings.HasSuffix(string(status), "Infeasible") {
+			return fmt.Errorf("resize failed (%s): %s", status, resizeConditionMessage(pvc))
+		}
+		for _, cond := range pvc.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue || cond.Type == reported {
+				continue
+			}
+			switch cond.Type {
+			case corev1.PersistentVolumeClaimResizing:
+				fmt.Fprintln(out, "Expanding storage...")
+				reported = cond.Type
+			case corev1.PersistentVolumeClaimFileSystemResizePending:
+				if !mounted {
+					fmt.Fprintln(out, "Storage expanded")
+					return errFileSystemResizePending
+				}
+				fmt.Fprintln(out, "Storage expanded, waiting for the mounted file system to grow...")
+				reported = cond.Type
+				onlineDeadline = time.After(onlineResizeTimeout)
+			}
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("timed out waiting for the resize; it continues in the background, check progress with 'sgs get volume %s'",
+				FormatVolumePath(pvc.Labels[sgs.LabelNodeName], pvc.Labels[sgs.LabelVolumeName]))
+		case <-onlineDeadline:
+			return errFileSystemResizePending
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch on volume ended unexpectedly")
+			}
+			switch event.Type {
+			case watch.Error:
+				return client.FormatK8sError(errors.FromObject(event.Object), "watch", "volume", c.Namespace)
+			case watch.Deleted:
+				return fmt.Errorf("volume was deleted during the resize")
+			}
+			if p, ok := event.Object.(*corev1.PersistentVolumeClaim); ok {
+				pvc = p
+			}
+		}
+	}
+}
+
+// resizeConditionMessage returns the message of the latest resize condition of pvc
+func resizeConditionMessage(pvc *corev1.PersistentVolumeClaim) string {
+	message := "no details reported"
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Message != "" {
+			message = cond.Message
+		}
+	}
+	return message
+}
+
+// sessionsUsingPVC returns the active sessions (node/volume) that mount a PVC,
+// either as their OS volume or as an additional mount
+func sessionsUsingPVC(ctx context.Context, c *client.Client, name string) ([]string, error) {
+	pods, err := client.RetryWithContext(ctx, func() (*corev1.PodList, error) {
+		return c.Clientset.CoreV1().Pods(c.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=sgs,%s", sgs.LabelManagedBy, sgs.LabelSessionMode),
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "sessions", c.Namespace)
+	}
+
+	var sessions []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == name {
+				nodeName := pod.Labels[sgs.LabelNodeName]
+				volumeName := strings.TrimPrefix(pod.Labels[sgs.LabelVolumeName], nodeName+"-")
+				sessions = append(sessions, FormatVolumePath(nodeName, volumeName))
+				break
+			}
+		}
+	}
+	return sessions, nil
+}
@@ -0,0 +1,230 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/session"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Values of the setup annotation
+const (
+	SetupRunning = "running"
+	SetupFailed  = "failed"
+)
+
+// Volume statuses shown instead of the PVC phase while a volume is not ready
+const (
+	StatusSettingUp   = "SettingUp"
+	StatusSetupFailed = "SetupFailed"
+)
+
+// setupStartGrace is how long a new volume may have no setup pod: it is
+// created once the volume is bound, which waitForBinderPod allows 5 minutes
+const setupStartGrace = 10 * time.Minute
+
+// setupScriptEnv holds the setup script in the setup pod
+const setupScriptEnv = "SGS_SETUP_SCRIPT"
+
+// setupRunner writes the setup script to a file and runs it, so that its
+// shebang line (e.g. #!/bin/bash) is honored. Scripts without one run with sh.
+const setupRunner = `printf '%s' "$` + setupScriptEnv + `" > /tmp/sgs-setup && chmod +x /tmp/sgs-setup || exit 1
+/tmp/sgs-setup
+rc=$?
+rm -f /tmp/sgs-setup
+exit $rc`
+
+// setupStatus returns the status of a volume whose setup has not succeeded,
+// or "" if it is ready. The sgs process that created the volume records the
+// result of the setup; if it died before that, the setup pod tells the
+// result, and it is recorded here.
+func setupStatus(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim) string {
+	switch pvc.Annotations[sgs.AnnotationSetup] {
+	case SetupRunning:
+		state, done := orphanedSetupState(ctx, c, pvc)
+		if !done {
+			return StatusSettingUp
+		}
+		if err := setSetupState(ctx, c, pvc.Name, state); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record the setup result of %s: %v\n", pvc.Name, err)
+		}
+		if state == SetupFailed {
+			return StatusSetupFailed
+		}
+	case SetupFailed:
+		return StatusSetupFailed
+	}
+	return ""
+}
+
+// orphanedSetupState checks whether the setup of a volume marked as running
+// is over, and returns the state to record: "" if the setup pod succeeded,
+// SetupFailed if it failed or is gone. On errors the setup is assumed to be
+// still running.
+func orphanedSetupState(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim) (state string, done bool) {
+	pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, setupPodName(pvc.Name), metav1.GetOptions{})
+	if err == nil {
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			return "", true
+		case corev1.PodFailed:
+			return SetupFailed, true
+		}
+		return "", false
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", false
+	}
+	// Before the setup pod, the volume is being bound
+	if time.Since(pvc.CreationTimestamp.Time) < setupStartGrace {
+		return "", false
+	}
+	return SetupFailed, true
+}
+
+// setupPodName returns the name of the pod that runs the setup script of a PVC
+func setupPodName(pvcName string) string {
+	return "setup-" + pvcName
+}
+
+// runSetup runs a setup script on a new OS volume in a provisioning pod,
+// which sees the volume as its root filesystem like a session does, so that
+// everything the script installs is kept in the volume. The script's output
+// is streamed to stdout. The volume is marked ready only if the script
+// succeeds; otherwise it is kept, marked as failed, for inspection.
+func runSetup(ctx context.Context, c *client.Client, opts CreateOptions, pvcName string) error {
+	volumePath := FormatVolumePath(opts.NodeName, opts.VolumeName)
+
+	pod := createSetupPodSpec(pvcName, opts.NodeName, opts.Image, opts.Setup, c.Namespace)
+	podName := pod.Name
+	markFailed := func(ctx context.Context) error {
+		return setSetupState(ctx, c, pvcName, SetupFailed)
+	}
+	if _, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		_ = markFailed(context.Background())
+		return client.FormatK8sError(err, "create", "setup pod", c.Namespace)
+	}
+
+	// Register cleanup for interrupt handling
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "Stopping setup...")
+		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(cleanupCtx, podName, metav1.DeleteOptions{})
+		if err := markFailed(cleanupCtx); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, " done (volume %s marked as failed)\n", volumePath)
+		}
+	})
+	fail := func(err error) error {
+		cleanup.Unregister()
+		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+		if markErr := markFailed(context.Background()); markErr != nil {
+			return fmt.Errorf("%w (marking the volume as failed also failed: %v)", err, markErr)
+		}
+		return fmt.Errorf("%w\nThe volume was kept for inspection ('sgs create session %s'); delete it with 'sgs delete volume %s'",
+			err, volumePath, volumePath)
+	}
+
+	fmt.Println("Running setup script...")
+	if err := waitForPodRunning(ctx, c, podName, 5*time.Minute); err != nil {
+		if cleanup.WasInterrupted() {
+			cleanup.WaitForCleanup()
+			return nil
+		}
+		// A script that finishes quickly may be done before the pod is seen running
+		if pod, getErr := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{}); getErr != nil ||
+			(pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed) {
+			return fail(fmt.Errorf("setup pod failed to start: %w", err))
+		}
+	}
+
+	if err := session.FollowPodLogs(ctx, c, podName, "main", os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Warning: setup output unavailable: %v\n", err)
+	}
+	completed, err := WaitForPod(ctx, c, podName, 0, PodCompleted)
+	if cleanup.WasInterrupted() {
+		cleanup.WaitForCleanup()
+		return nil
+	}
+	if err != nil {
+		return fail(fmt.Errorf("setup did not complete: %w", err))
+	}
+	if completed.Status.Phase != corev1.PodSucceeded {
+		exitCode := int32(1)
+		for _, cs := range completed.Status.ContainerStatuses {
+			if cs.State.Terminated != nil {
+				exitCode = cs.State.Terminated.ExitCode
+			}
+		}
+		return fail(fmt.Errorf("setup script of %s failed with exit code %d", volumePath, exitCode))
+	}
+
+	cleanup.Unregister()
+	_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+	return setSetupState(ctx, c, pvcName, "")
+}
+
+// setSetupState sets the setup annotation of a PVC, or removes it if state is ""
+func setSetupState(ctx context.Context, c *client.Client, pvcName, state string) error {
+	var value any
+	if state != "" {
+		value = state
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{sgs.AnnotationSetup: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
+	if _, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Patch(ctx, pvcName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return client.FormatK8sError(err, "update", "volume", c.Namespace)
+	}
+	return nil
+}
+
+// createSetupPodSpec creates a pod that runs a setup script on an OS volume.
+// It is an edit session without a terminal or GPU, and is not listed as a session.
+func createSetupPodSpec(pvcName, nodeName, image, script, namespace string) *corev1.Pod {
+	pod := createEditPodSpec(setupPodName(pvcName), pvcName, nodeName, "", image, nil, namespace)
+	pod.Labels = map[string]string{
+		sgs.LabelManagedBy:    "sgs",
+		"sgs.snucse.org/mode": "setup",
+	}
+
+	container := &pod.Spec.Containers[0]
+	delete(container.Resources.Limits, corev1.ResourceName("nvidia.com/gpu"))
+	delete(container.Resources.Limits, corev1.ResourceName("nvidia.com/gpumem"))
+	container.Stdin = false
+	container.TTY = false
+	container.Command = []string{"/bin/sh", "-c", setupRunner}
+	container.Env = []corev1.EnvVar{{Name: setupScriptEnv, Value: script}}
+	return pod
+}
+
+// checkNotSettingUp returns an error if the setup script of a volume is still
+// running. Volumes whose setup failed can still be used, to inspect them.
+func checkNotSettingUp(ctx context.Context, c *client.Client, nodeName, volumeName string) error {
+	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, PVCName(nodeName, volumeName), metav1.GetOptions{})
+	})
+	if err != nil {
+		return client.FormatK8sError(err, "get", "volume", c.Namespace)
+	}
+	if setupStatus(ctx, c, pvc) == StatusSettingUp {
+		return fmt.Errorf("volume %s is still being set up; try again once 'sgs get volumes' no longer shows it as %s",
+			FormatVolumePath(nodeName, volumeName), StatusSettingUp)
+	}
+	return nil
+}
@@ -0,0 +1,697 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// Snapshot methods
+const (
+	SnapshotMethodAuto = "auto" // CSI if the storage class supports it, tar otherwise
+	SnapshotMethodCSI  = "csi"  // CSI VolumeSnapshot
+	SnapshotMethodTar  = "tar"  // Tar archive in a data volume on the same node
+)
+
+// snapshotReadyTimeout bounds how long CreateSnapshot waits for a CSI snapshot
+const snapshotReadyTimeout = 10 * time.Minute
+
+var (
+	volumeSnapshotGVR      = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+	volumeSnapshotClassGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotclasses"}
+)
+
+// SnapshotInfo represents a snapshot of a volume
+type SnapshotInfo struct {
+	Name       string    `json:"name" yaml:"name"`
+	NodeName   string    `json:"nodeName" yaml:"nodeName"`
+	VolumeName string    `json:"volumeName" yaml:"volumeName"` // Source volume
+	Method     string    `json:"method" yaml:"method"`         // csi or tar
+	Status     string    `json:"status" yaml:"status"`         // Ready, Pending or Failed
+	Size       string    `json:"size" yaml:"size"`
+	Image      string    `json:"image,omitempty" yaml:"image,omitempty"` // OS image of the source volume
+	Age        string    `json:"age" yaml:"age"`
+	CreatedAt  time.Time `json:"createdAt" yaml:"createdAt"`
+	Error      string    `json:"error,omitempty" yaml:"error,omitempty"`
+
+	object string // VolumeSnapshot or tar snapshot PVC name
+}
+
+// SnapshotOptions holds options for creating a snapshot
+type SnapshotOptions struct {
+	NodeName   string
+	VolumeName string
+	Name       string // Snapshot name (default: timestamp)
+	Method     string // auto, csi or tar (default: auto)
+}
+
+// RestoreOptions holds options for restoring a snapshot
+type RestoreOptions struct {
+	NodeName   string
+	VolumeName string
+	Name       string // Snapshot name
+	DstVolume  string // Volume to restore into on the same node (empty = replace the source volume)
+}
+
+// snapshotVolumeName returns the name of the data volume holding a tar snapshot
+func snapshotVolumeName(volumeName, snapshotName string) string {
+	return volumeName + "-snap-" + snapshotName
+}
+
+// CreateSnapshot takes a snapshot of a volume. With the auto method, it uses a
+// CSI VolumeSnapshot if the volume's storage class supports it, and a tar
+// archive in a data volume on the same node otherwise.
+func CreateSnapshot(ctx context.Context, c *client.Client, opts SnapshotOptions) (*SnapshotInfo, error) {
+	if opts.Name == "" {
+		opts.Name = time.Now().Format("20060102-150405")
+	}
+	if opts.Method == "" {
+		opts.Method = SnapshotMethodAuto
+	}
+
+	name := PVCName(opts.NodeName, opts.VolumeName)
+	volumePath := FormatVolumePath(opts.NodeName, opts.VolumeName)
+
+	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("volume %s not found", volumePath)
+		}
+		return nil, client.FormatK8sError(err, "get", "volume", c.Namespace)
+	}
+	if pvc.Labels[sgs.LabelSnapshotName] != "" {
+		return nil, fmt.Errorf("%s is a snapshot volume; restore it with 'sgs snapshot restore' instead", volumePath)
+	}
+
+	if _, err := GetSnapshot(ctx, c, opts.NodeName, opts.VolumeName, opts.Name); err == nil {
+		return nil, fmt.Errorf("snapshot %q of %s already exists", opts.Name, volumePath)
+	}
+
+	method := opts.Method
+	snapshotClass := ""
+	if method != SnapshotMethodTar {
+		var err error
+		snapshotClass, err = csiSnapshotClass(ctx, c, pvc)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case snapshotClass != "":
+			method = SnapshotMethodCSI
+		case method == SnapshotMethodCSI:
+			return nil, fmt.Errorf("the storage class of %s does not support CSI snapshots (use --method tar)", volumePath)
+		default:
+			method = SnapshotMethodTar
+		}
+	}
+
+	// Check the names the snapshot gets before creating anything
+	snapVolume := opts.VolumeName + "-" + opts.Name // VolumeSnapshot <node>-<volume>-<name>
+	if method == SnapshotMethodTar {
+		snapVolume = snapshotVolumeName(opts.VolumeName, opts.Name)
+	}
+	if err := CheckNameLength(opts.NodeName, snapVolume); err != nil {
+		return nil, fmt.Errorf("snapshot name %q is too long: %w", opts.Name, err)
+	}
+
+	if method == SnapshotMethodCSI {
+		return createCSISnapshot(ctx, c, opts, pvc, snapshotClass)
+	}
+	return createTarSnapshot(ctx, c, opts, pvc)
+}
+
+// csiSnapshotClass returns the VolumeSnapshotClass for the CSI driver of pvc,
+// or "" if snapshots are not supported (or we may not check)
+func csiSnapshotClass(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return "", nil
+	}
+	sc, err := c.Clientset.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if errors.IsNotFound(err) || errors.IsForbidden(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", client.FormatK8sError(err, "get", "storage class", "cluster")
+	}
+
+	dyn, err := dynamic.NewForConfig(c.Config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create client: %w", err)
+	}
+	// NotFound if the snapshot CRDs are not installed
+	classes, err := dyn.Resource(volumeSnapshotClassGVR).List(ctx, metav1.ListOptions{})
+	if errors.IsNotFound(err) || errors.IsForbidden(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", client.FormatK8sError(err, "list", "snapshot classes", "cluster")
+	}
+
+	found := ""
+	for _, class := range classes.Items {
+		driver, _, _ := unstructured.NestedString(class.Object, "driver")
+		if driver != sc.Provisioner {
+			continue
+		}
+		if class.GetAnnotations()["snapshot.storage.kubernetes.io/is-default-class"] == "true" {
+			return class.GetName(), nil
+		}
+		if found == "" {
+			found = class.GetName()
+		}
+	}
+	return found, nil
+}
+
+// createCSISnapshot creates a VolumeSnapshot of pvc and waits for it to be ready
+func createCSISnapshot(ctx context.Context, c *client.Client, opts SnapshotOptions, pvc *corev1.PersistentVolumeClaim, snapshotClass string) (*SnapshotInfo, error) {
+	dyn, err := dynamic.NewForConfig(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	snapshot := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"spec": map[string]any{
+			"volumeSnapshotClassName": snapshotClass,
+			"source": map[string]any{
+				"persistentVolumeClaimName": pvc.Name,
+			},
+		},
+	}}
+	snapshot.SetName(pvc.Name + "-" + opts.Name)
+	snapshot.SetNamespace(c.Namespace)
+	snapshot.SetLabels(map[string]string{
+		sgs.LabelManagedBy:    "sgs",
+		sgs.LabelNodeName:     opts.NodeName,
+		sgs.LabelVolumeName:   opts.VolumeName,
+		sgs.LabelSnapshotName: opts.Name,
+	})
+	snapshot.SetAnnotations(snapshotAnnotations(pvc))
+
+	fmt.Printf("Creating CSI snapshot %q of %s/%s...\n", opts.Name, opts.NodeName, opts.VolumeName)
+	snapshot, err = dyn.Resource(volumeSnapshotGVR).Namespace(c.Namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "create", "snapshot", c.Namespace)
+	}
+
+	// Wait for the snapshot to be cut
+	waitCtx, cancel := context.WithTimeout(ctx, snapshotReadyTimeout)
+	defer cancel()
+	selector := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", snapshot.GetName()).String(),
+	}
+	w, err := client.NewRetryWatcher(waitCtx, snapshot.GetResourceVersion(), selector, dyn.Resource(volumeSnapshotGVR).Namespace(c.Namespace).Watch)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	for {
+		info := csiSnapshotToInfo(snapshot)
+		switch info.Status {
+		case "Ready":
+			return &info, nil
+		case "Failed":
+			return nil, fmt.Errorf("snapshot failed: %s", info.Error)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("timed out waiting for the snapshot to be ready; check its status with 'sgs snapshot list'")
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watch on snapshot ended unexpectedly")
+			}
+			switch event.Type {
+			case watch.Error:
+				return nil, client.FormatK8sError(errors.FromObject(event.Object), "watch", "snapshot", c.Namespace)
+			case watch.Deleted:
+				return nil, fmt.Errorf("snapshot was deleted while it was being created")
+			}
+			if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+				snapshot = obj
+			}
+		}
+	}
+}
+
+// createTarSnapshot archives pvc into a new data volume on the same node
+func createTarSnapshot(ctx context.Context, c *client.Client, opts SnapshotOptions, pvc *corev1.PersistentVolumeClaim) (*SnapshotInfo, error) {
+	// A tar archive of a volume in use would be inconsistent
+	mode, err := GetSessionMode(ctx, c, opts.NodeName, opts.VolumeName)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		return nil, fmt.Errorf("volume %s/%s has an active session, please delete it first (its storage class has no CSI snapshot support, so the snapshot is a tar archive)", opts.NodeName, opts.VolumeName)
+	}
+
+	snapVolume := snapshotVolumeName(opts.VolumeName, opts.Name)
+	snapPVCName := PVCName(opts.NodeName, snapVolume)
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	labels := map[string]string{
+		sgs.LabelManagedBy:    "sgs",
+		sgs.LabelNodeName:     opts.NodeName,
+		sgs.LabelVolumeName:   snapVolume,
+		sgs.LabelSnapshotOf:   opts.VolumeName,
+		sgs.LabelSnapshotName: opts.Name,
+	}
+	snapPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        snapPVCName,
+			Namespace:   c.Namespace,
+			Labels:      labels,
+			Annotations: snapshotAnnotations(pvc),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	fmt.Printf("Creating tar snapshot %q of %s/%s in data volume %s/%s (%s)...\n",
+		opts.Name, opts.NodeName, opts.VolumeName, opts.NodeName, snapVolume, size.String())
+	snapPVC, err = c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(ctx, snapPVC, metav1.CreateOptions{})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "create", "snapshot volume", c.Namespace)
+	}
+
+	// Register cleanup for the snapshot volume in case of interrupt
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "Cleaning up snapshot volume...")
+		if err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(cleanupCtx, snapPVCName, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, " done")
+		}
+	})
+
+	err = runCopyPod(ctx, c, "snapshot-"+snapPVCName, opts.NodeName, pvc.Name, snapPVCName,
+		"tar -cf /dst/snapshot.tar -C /src . && sync")
+	if err == nil {
+		err = markSnapshotComplete(ctx, c, snapPVCName)
+	}
+	if err != nil {
+		// If interrupted, signal handler does cleanup - just wait and return
+		if cleanup.WasInterrupted() {
+			cleanup.WaitForCleanup()
+			return nil, err
+		}
+		cleanup.Unregister()
+		fmt.Print("Snapshot failed, cleaning up snapshot volume...")
+		_ = c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(context.Background(), snapPVCName, metav1.DeleteOptions{})
+		fmt.Println(" done")
+		return nil, err
+	}
+	cleanup.Unregister()
+
+	snapPVC.Annotations[sgs.AnnotationSnapshotComplete] = "true"
+	info := tarSnapshotToInfo(snapPVC)
+	return &info, nil
+}
+
+// snapshotAnnotations returns the annotations that let a snapshot of pvc be restored as an equivalent volume
+func snapshotAnnotations(pvc *corev1.PersistentVolumeClaim) map[string]string {
+	annotations := map[string]string{}
+	if image := pvc.Annotations[sgs.AnnotationOSImage]; image != "" {
+		annotations[sgs.AnnotationSnapshotImage] = image
+	}
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+		annotations[sgs.AnnotationSnapshotStorageClass] = *pvc.Spec.StorageClassName
+	}
+	// CSI drivers need not report the restore size of a snapshot
+	if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		annotations[sgs.AnnotationSnapshotSize] = size.String()
+	}
+	return annotations
+}
+
+// markSnapshotComplete records that the tar archive in a snapshot volume is complete
+func markSnapshotComplete(ctx context.Context, c *client.Client, snapPVCName string) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, sgs.AnnotationSnapshotComplete)
+	_, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Patch(ctx, snapPVCName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return client.FormatK8sError(err, "update", "snapshot volume", c.Namespace)
+	}
+	return nil
+}
+
+// ListSnapshots returns all snapshots in the current namespace, CSI and tar, sorted by volume and age
+func ListSnapshots(ctx context.Context, c *client.Client) ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+
+	dyn, err := dynamic.NewForConfig(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	list, err := dyn.Resource(volumeSnapshotGVR).Namespace(c.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=sgs,%s", sgs.LabelManagedBy, sgs.LabelSnapshotName),
+	})
+	switch {
+	case err == nil:
+		for i := range list.Items {
+			snapshots = append(snapshots, csiSnapshotToInfo(&list.Items[i]))
+		}
+	case errors.IsNotFound(err), errors.IsForbidden(err):
+		// Snapshot CRDs are not installed (or not open to us), so there are only tar snapshots
+	default:
+		return nil, client.FormatK8sError(err, "list", "snapshots", c.Namespace)
+	}
+
+	pvcs, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaimList, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=sgs,%s", sgs.LabelManagedBy, sgs.LabelSnapshotName),
+		})
+	})
+	if err != nil {
+		return nil, client.FormatK8sError(err, "list", "snapshot volumes", c.Namespace)
+	}
+	for i := range pvcs.Items {
+		snapshots = append(snapshots, tarSnapshotToInfo(&pvcs.Items[i]))
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if a.NodeName != b.NodeName {
+			return a.NodeName < b.NodeName
+		}
+		if a.VolumeName != b.VolumeName {
+			return a.VolumeName < b.VolumeName
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// GetSnapshot returns a snapshot of a volume by name
+func GetSnapshot(ctx context.Context, c *client.Client, nodeName, volumeName, snapshotName string) (*SnapshotInfo, error) {
+	snapshots, err := ListSnapshots(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		if s.NodeName == nodeName && s.VolumeName == volumeName && s.Name == snapshotName {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot %q of %s/%s not found", snapshotName, nodeName, volumeName)
+}
+
+// csiSnapshotToInfo converts a VolumeSnapshot to SnapshotInfo
+func csiSnapshotToInfo(obj *unstructured.Unstructured) SnapshotInfo {
+	labels := obj.GetLabels()
+	annotations := obj.GetAnnotations()
+	info := SnapshotInfo{
+		Name:       labels[sgs.LabelSnapshotName],
+		NodeName:   labels[sgs.LabelNodeName],
+		VolumeName: labels[sgs.LabelVolumeName],
+		Method:     SnapshotMethodCSI,
+		Status:     "Pending",
+		Size:       "N/A",
+		Image:      annotations[sgs.AnnotationSnapshotImage],
+		Age:        sgs.FormatAge(time.Since(obj.GetCreationTimestamp().Time)),
+		CreatedAt:  obj.GetCreationTimestamp().Time,
+		object:     obj.GetName(),
+	}
+	if ready, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse"); ready {
+		info.Status = "Ready"
+	}
+	if size, _, _ := unstructured.NestedString(obj.Object, "status", "restoreSize"); size != "" {
+		info.Size = size
+	} else if size := annotations[sgs.AnnotationSnapshotSize]; size != "" {
+		info.Size = size
+	}
+	if message, _, _ := unstructured.NestedString(obj.Object, "status", "error", "message"); message != "" {
+		info.Status = "Failed"
+		info.Error = message
+	}
+	return info
+}
+
+// tarSnapshotToInfo converts a tar snapshot volume to SnapshotInfo
+func tarSnapshotToInfo(pvc *corev1.PersistentVolumeClaim) SnapshotInfo {
+	info := SnapshotInfo{
+		Name:       pvc.Labels[sgs.LabelSnapshotName],
+		NodeName:   pvc.Labels[sgs.LabelNodeName],
+		VolumeName: pvc.Labels[sgs.LabelSnapshotOf],
+		Method:     SnapshotMethodTar,
+		Status:     "Pending",
+		Size:       "N/A",
+		Image:      pvc.Annotations[sgs.AnnotationSnapshotImage],
+		Age:        sgs.FormatAge(time.Since(pvc.CreationTimestamp.Time)),
+		CreatedAt:  pvc.CreationTimestamp.Time,
+		object:     pvc.Name,
+	}
+	// An archive that was interrupted is never marked complete
+	if pvc.Annotations[sgs.AnnotationSnapshotComplete] == "true" {
+		info.Status = "Ready"
+	}
+	if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		info.Size = storage.String()
+	}
+	return info
+}
+
+// DeleteSnapshot deletes a snapshot of a volume
+func DeleteSnapshot(ctx context.Context, c *client.Client, nodeName, volumeName, snapshotName string) error {
+	snapshot, err := GetSnapshot(ctx, c, nodeName, volumeName, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Method == SnapshotMethodTar {
+		err = c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(ctx, snapshot.object, metav1.DeleteOptions{})
+	} else {
+		dyn, dynErr := dynamic.NewForConfig(c.Config)
+		if dynErr != nil {
+			return fmt.Errorf("failed to create client: %w", dynErr)
+		}
+		err = dyn.Resource(volumeSnapshotGVR).Namespace(c.Namespace).Delete(ctx, snapshot.object, metav1.DeleteOptions{})
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return client.FormatK8sError(err, "delete", "snapshot", c.Namespace)
+	}
+	return nil
+}
+
+// RestoreSnapshot restores a snapshot into a new volume on the same node, or
+// replaces the source volume if opts.DstVolume is empty. Restored OS volumes
+// keep the OS image of the source volume, so they stay bootable.
+func RestoreSnapshot(ctx context.Context, c *client.Client, opts RestoreOptions) error {
+	snapshot, err := GetSnapshot(ctx, c, opts.NodeName, opts.VolumeName, opts.Name)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status != "Ready" {
+		return fmt.Errorf("snapshot %q is not ready (status: %s)", opts.Name, snapshot.Status)
+	}
+
+	replace := opts.DstVolume == "" || opts.DstVolume == opts.VolumeName
+	dstVolume := opts.DstVolume
+	if replace {
+		dstVolume = opts.VolumeName
+	}
+	dstPVCName := PVCName(opts.NodeName, dstVolume)
+
+	// The destination must not be in use while its contents are replaced
+	mode, err := GetSessionMode(ctx, c, opts.NodeName, dstVolume)
+	if err != nil {
+		return err
+	}
+	if mode != "" {
+		return fmt.Errorf("volume %s/%s has an active session, please delete it first", opts.NodeName, dstVolume)
+	}
+
+	if !replace {
+		if _, err := Get(ctx, c, opts.NodeName, dstVolume); err == nil {
+			return fmt.Errorf("destination volume %s/%s already exists", opts.NodeName, dstVolume)
+		}
+	}
+
+	if snapshot.Method == SnapshotMethodCSI {
+		return restoreCSISnapshot(ctx, c, snapshot, opts.NodeName, dstVolume, replace)
+	}
+	return restoreTarSnapshot(ctx, c, snapshot, opts.NodeName, dstVolume, dstPVCName, replace)
+}
+
+// restoredVolumePVC returns the PVC for a volume restored from snapshot
+func restoredVolumePVC(c *client.Client, snapshot *SnapshotInfo, nodeName, volumeName, storageClass string) (*corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(snapshot.Size)
+	if err != nil {
+		return nil, fmt.Errorf("size of snapshot %q is unknown (%s), so it cannot be restored", snapshot.Name, snapshot.Size)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PVCName(nodeName, volumeName),
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				sgs.LabelManagedBy:  "sgs",
+				sgs.LabelNodeName:   nodeName,
+				sgs.LabelVolumeName: volumeName,
+			},
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	// Keep the OS image so the restored volume stays bootable
+	if snapshot.Image != "" {
+		pvc.Annotations[sgs.AnnotationOSImage] = snapshot.Image
+	}
+	return pvc, nil
+}
+
+// restoreCSISnapshot provisions a volume from a VolumeSnapshot. When replacing,
+// the source volume is deleted first; the snapshot does not depend on it.
+func restoreCSISnapshot(ctx context.Context, c *client.Client, snapshot *SnapshotInfo, nodeName, dstVolume string, replace bool) error {
+	dyn, err := dynamic.NewForConfig(c.Config)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	obj, err := dyn.Resource(volumeSnapshotGVR).Namespace(c.Namespace).Get(ctx, snapshot.object, metav1.GetOptions{})
+	if err != nil {
+		return client.FormatK8sError(err, "get", "snapshot", c.Namespace)
+	}
+
+	pvc, err := restoredVolumePVC(c, snapshot, nodeName, dstVolume, obj.GetAnnotations()[sgs.AnnotationSnapshotStorageClass])
+	if err != nil {
+		return err
+	}
+	apiGroup := volumeSnapshotGVR.Group
+	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     "VolumeSnapshot",
+		Name:     snapshot.object,
+	}
+
+	if replace {
+		fmt.Printf("Deleting volume %s/%s...\n", nodeName, dstVolume)
+		if err := deletePVCAndWait(ctx, c, pvc.Name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Restoring snapshot %q into %s/%s...\n", snapshot.Name, nodeName, dstVolume)
+	if _, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		if replace {
+			return fmt.Errorf("%w; the volume was deleted, restore again with 'sgs snapshot restore %s/%s %s --to <volume>'",
+				client.FormatK8sError(err, "create", "volume", c.Namespace), nodeName, snapshot.VolumeName, snapshot.Name)
+		}
+		return client.FormatK8sError(err, "create", "volume", c.Namespace)
+	}
+	return nil
+}
+
+// restoreTarSnapshot extracts a tar snapshot into a volume on the same node.
+// When replacing, the existing contents of the volume are removed first.
+func restoreTarSnapshot(ctx context.Context, c *client.Client, snapshot *SnapshotInfo, nodeName, dstVolume, dstPVCName string, replace bool) error {
+	if !replace {
+		pvc, err := restoredVolumePVC(c, snapshot, nodeName, dstVolume, "")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Creating volume %s/%s (%s)...\n", nodeName, dstVolume, snapshot.Size)
+		if _, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+			return client.FormatK8sError(err, "create", "volume", c.Namespace)
+		}
+
+		// Register cleanup for the destination PVC in case of interrupt
+		cleanup.Register(func(cleanupCtx context.Context) {
+			fmt.Fprint(os.Stderr, "Cleaning up destination volume...")
+			if err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(cleanupCtx, dstPVCName, metav1.DeleteOptions{}); err != nil {
+				fmt.Fprintf(os.Stderr, " failed: %v\n", err)
+			} else {
+				fmt.Fprintln(os.Stderr, " done")
+			}
+		})
+	}
+
+	fmt.Printf("Restoring snapshot %q into %s/%s...\n", snapshot.Name, nodeName, dstVolume)
+	script := "tar -xf /src/snapshot.tar -C /dst"
+	if replace {
+		script = "rm -rf /dst/* /dst/.[!.]* /dst/..?* && " + script
+	}
+	err := runCopyPod(ctx, c, "restore-"+dstPVCName, nodeName, snapshot.object, dstPVCName, script)
+
+	if !replace {
+		if err != nil {
+			// If interrupted, signal handler does cleanup - just wait and return
+			if cleanup.WasInterrupted() {
+				cleanup.WaitForCleanup()
+				return err
+			}
+			cleanup.Unregister()
+			fmt.Print("Restore failed, cleaning up destination volume...")
+			_ = c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(context.Background(), dstPVCName, metav1.DeleteOptions{})
+			fmt.Println(" done")
+			return err
+		}
+		cleanup.Unregister()
+	}
+	return err
+}
+
+// deletePVCAndWait deletes a PVC and waits until it is gone
+func deletePVCAndWait(ctx context.Context, c *client.Client, name string) error {
+	err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return client.FormatK8sError(err, "delete", "volume", c.Namespace)
+	}
+
+	deadline := time.Now().Add(2 * time.Minute)
+	for time.Now().Before(deadline) {
+		_, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			// continue polling
+		}
+	}
+	return fmt.Errorf("timeout waiting for volume deletion")
+}
@@ -0,0 +1,141 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// SSHPort is the port sshd listens on inside session pods
+const SSHPort = 22
+
+// sshdBootstrapScript installs and starts sshd inside a session. It is idempotent,
+// so it can run on every connection. The public key to authorize is read from stdin.
+// Packages are installed into the OS volume, so this only downloads on first use.
+const sshdBootstrapScript = `set -e
+KEY="$(cat)"
+if [ ! -x /usr/sbin/sshd ]; then
+  echo "Installing sshd (first use)..."
+  if command -v apt-get >/dev/null 2>&1; then
+    export DEBIAN_FRONTEND=noninteractive
+    apt-get update -qq && apt-get install -y -qq openssh-server >/dev/null
+  elif command -v apk >/dev/null 2>&1; then
+    apk add --no-cache openssh-server >/dev/null
+  elif command -v dnf >/dev/null 2>&1; then
+    dnf install -y -q openssh-server
+  elif command -v yum >/dev/null 2>&1; then
+    yum install -y -q openssh-server
+  else
+    echo "cannot install sshd: no supported package manager found" >&2
+    exit 1
+  fi
+fi
+mkdir -p /run/sshd /root/.ssh
+chmod 700 /root/.ssh
+touch /root/.ssh/authorized_keys
+chmod 600 /root/.ssh/authorized_keys
+if [ -n "$KEY" ] && ! grep -qxF "$KEY" /root/.ssh/authorized_keys; then
+  echo "$KEY" >> /root/.ssh/authorized_keys
+fi
+ssh-keygen -A >/dev/null
+if [ -f /run/sgs-sshd.pid ] && kill -0 "$(cat /run/sgs-sshd.pid)" 2>/dev/null; then
+  exit 0
+fi
+/usr/sbin/sshd -o PidFile=/run/sgs-sshd.pid -o PermitRootLogin=prohibit-password -o PasswordAuthentication=no
+`
+
+// BootstrapSSHD makes sure sshd is installed and running in a session pod,
+// with publicKey added to root's authorized_keys. Progress is written to out.
+func BootstrapSSHD(ctx context.Context, c *client.Client, podName, publicKey string, out io.Writer) error {
+	var stderr strings.Builder
+	cmd := []string{"/bin/sh", "-c", sshdBootstrapScript}
+	if err := execInPod(ctx, c, podName, cmd, strings.NewReader(publicKey), out, &stderr); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("failed to start sshd: %s", strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("failed to start sshd: %w", err)
+	}
+	return nil
+}
+
+// ProxyPort connects stdin/stdout to a TCP port inside a pod over a single
+// port-forward stream, without opening a local listener. This is the
+// transport needed by an OpenSSH ProxyCommand.
+func ProxyPort(ctx context.Context, c *client.Client, podName string, port int, stdin io.Reader, stdout io.Writer) error {
+	dialer, err := portForwardDialer(c, podName)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return client.FormatK8sError(err, "connect to", "session", c.Namespace)
+	}
+	defer conn.Close()
+
+	// Create error stream (we only read from it)
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create error stream: %w", err)
+	}
+	errorStream.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			errCh <- fmt.Errorf("failed to read error stream: %w", err)
+		case len(message) > 0:
+			errCh <- fmt.Errorf("port %d: %s", port, message)
+		default:
+			errCh <- nil
+		}
+	}()
+
+	// Create data stream
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create data stream: %w", err)
+	}
+
+	remoteDone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(stdout, dataStream)
+		close(remoteDone)
+	}()
+	go func() {
+		// Tell the remote side we are done sending once stdin is closed
+		defer dataStream.Close()
+		_, _ = io.Copy(dataStream, stdin)
+	}()
+
+	select {
+	case <-remoteDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Discard unsent data so the error stream is not blocked
+	_ = dataStream.Reset()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(5 * time.Second):
+		return nil
+	}
+}
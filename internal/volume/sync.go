@@ -0,0 +1,522 @@
+package volume
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+)
+
+// SyncEndpoint is the source or destination of a sync: a local directory or
+// a directory in a volume
+type SyncEndpoint struct {
+	LocalPath  string // Set for a local directory
+	NodeName   string
+	VolumeName string
+	Path       string // Directory in the volume (empty = volume root)
+}
+
+// ParseSyncPath parses a local path or "node/volume[:path]" into a SyncEndpoint
+func ParseSyncPath(arg string) (SyncEndpoint, error) {
+	if IsLocalPath(arg) {
+		localPath, err := expandLocalPath(arg)
+		if err != nil {
+			return SyncEndpoint{}, err
+		}
+		return SyncEndpoint{LocalPath: localPath}, nil
+	}
+	p, err := ParseCopyPath(arg)
+	if err != nil {
+		return SyncEndpoint{}, err
+	}
+	return SyncEndpoint{NodeName: p.NodeName, VolumeName: p.VolumeName, Path: p.Path}, nil
+}
+
+// IsLocal returns true if the endpoint is a local directory
+func (e SyncEndpoint) IsLocal() bool {
+	return e.LocalPath != ""
+}
+
+// String returns the endpoint in the format it was given
+func (e SyncEndpoint) String() string {
+	if e.IsLocal() {
+		return e.LocalPath
+	}
+	if e.Path == "" {
+		return FormatVolumePath(e.NodeName, e.VolumeName)
+	}
+	return FormatVolumePath(e.NodeName, e.VolumeName) + ":" + e.Path
+}
+
+// SyncOptions holds options for syncing two directories
+type SyncOptions struct {
+	Src      SyncEndpoint
+	Dst      SyncEndpoint
+	Delete   bool     // Delete destination files that are not in the source
+	Exclude  []string // Patterns of paths to skip on both ends
+	DryRun   bool     // Only report what would change
+	Checksum bool     // Compare file contents instead of modification times
+}
+
+// SyncResult reports what a sync changed (or would change, for a dry run)
+type SyncResult struct {
+	Transferred []string // Paths sent to the destination
+	Deleted     []string // Paths deleted from the destination
+	Unchanged   int
+	Bytes       int64 // Size of the files sent
+}
+
+// syncEntry is a file, directory or symbolic link in a sync manifest
+type syncEntry struct {
+	mode     fs.FileMode // Type and permission bits
+	size     int64
+	modTime  int64 // Unix seconds
+	checksum string
+}
+
+// syncManifest maps slash-separated paths relative to the synced directory to entries
+type syncManifest map[string]syncEntry
+
+// syncSide is one end of a sync with a way to list, send, receive and delete files
+type syncSide struct {
+	SyncEndpoint
+	c       *client.Client
+	podName string // Copy pod for volume endpoints
+	dir     string // Synced directory (local path, or path in the copy pod)
+}
+
+// Sync makes the destination directory match the source directory, sending
+// only new and changed files. Files are compared by size and modification time
+// (or content, with Checksum). An interrupted sync resumes where it left off
+// when run again, because completed files are unchanged on the next run.
+func Sync(ctx context.Context, c *client.Client, opts SyncOptions) (*SyncResult, error) {
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q", pattern)
+		}
+	}
+	if opts.Src.IsLocal() && opts.Dst.IsLocal() {
+		return nil, fmt.Errorf("both source and destination are local paths; use rsync instead")
+	}
+
+	src := &syncSide{SyncEndpoint: opts.Src, c: c}
+	dst := &syncSide{SyncEndpoint: opts.Dst, c: c}
+	stopSrc, err := src.open(ctx, "sync-src-", "source", true)
+	if err != nil {
+		return nil, err
+	}
+	defer stopSrc()
+	stopDst, err := dst.open(ctx, "sync-dst-", "destination", opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer stopDst()
+
+	fmt.Println("  Comparing files...")
+	srcFiles, err := src.manifest(ctx, opts.Exclude, opts.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source files: %w", err)
+	}
+	if srcFiles == nil {
+		return nil, fmt.Errorf("source directory %s does not exist", opts.Src)
+	}
+	dstFiles, err := dst.manifest(ctx, opts.Exclude, opts.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination files: %w", err)
+	}
+
+	result, replaced := diffManifests(srcFiles, dstFiles, opts.Delete, opts.Checksum)
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Entries whose type changed must go before the new ones arrive
+	if remove := append(replaced, result.Deleted...); len(remove) > 0 {
+		if err := dst.remove(ctx, topLevelPaths(remove)); err != nil {
+			return nil, fmt.Errorf("failed to delete destination files: %w", err)
+		}
+	}
+
+	if len(result.Transferred) == 0 {
+		return result, nil
+	}
+	fmt.Printf("  Sending %d files (%s)...\n", len(result.Transferred), FormatBytes(result.Bytes))
+
+	pr, pw := io.Pipe()
+	progress := newTransferProgress(pw, result.Bytes)
+	errChan := make(chan error, 2)
+
+	go func() {
+		err := src.send(ctx, result.Transferred, progress)
+		pw.CloseWithError(err)
+		errChan <- err
+	}()
+
+	go func() {
+		err := dst.receive(ctx, pr, opts.Src.IsLocal())
+		pr.CloseWithError(err)
+		errChan <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errChan; err != nil {
+			fmt.Println()
+			return nil, fmt.Errorf("sync stream failed: %w", err)
+		}
+	}
+	fmt.Printf("\r  %-72s\n", progress.summary())
+
+	return result, nil
+}
+
+// diffManifests returns the paths to send and delete, and the destination
+// paths that must be removed first because their type changed
+func diffManifests(src, dst syncManifest, deleteExtra, checksum bool) (*SyncResult, []string) {
+	result := &SyncResult{}
+	var replaced []string
+
+	for _, p := range sortedPaths(src) {
+		s := src[p]
+		d, ok := dst[p]
+		switch {
+		case !ok:
+		case s.mode.Type() != d.mode.Type():
+			replaced = append(replaced, p)
+		case !entryChanged(s, d, checksum):
+			result.Unchanged++
+			continue
+		}
+		result.Transferred = append(result.Transferred, p)
+		if s.mode.IsRegular() {
+			result.Bytes += s.size
+		}
+	}
+
+	if deleteExtra {
+		for _, p := range sortedPaths(dst) {
+			if _, ok := src[p]; !ok {
+				result.Deleted = append(result.Deleted, p)
+			}
+		}
+	}
+	return result, replaced
+}
+
+// entryChanged reports whether an entry differs between source and
+// destination, given that both have the same type
+func entryChanged(s, d syncEntry, checksum bool) bool {
+	switch {
+	case s.mode.IsDir():
+		return s.mode.Perm() != d.mode.Perm()
+	case s.mode&fs.ModeSymlink != 0:
+		// Link times are not preserved, so compare the length of the target
+		return s.size != d.size
+	case s.size != d.size || s.mode.Perm() != d.mode.Perm():
+		return true
+	case checksum:
+		return s.checksum != d.checksum
+	}
+	return s.modTime != d.modTime
+}
+
+// sortedPaths returns the paths of a manifest in order, parents before children
+func sortedPaths(m syncManifest) []string {
+	paths := make([]string, 0, len(m))
+	for p := range m {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// topLevelPaths drops paths that are inside another path of the list
+func topLevelPaths(paths []string) []string {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+
+	var top []string
+	for _, p := range paths {
+		inside := false
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if set[dir] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			top = append(top, p)
+		}
+	}
+	sort.Strings(top)
+	return top
+}
+
+// isExcluded reports whether a relative path or one of its parents matches an
+// exclude pattern. Patterns without "/" match a name at any depth; patterns
+// with "/" match the path from the synced directory (e.g. data/*.tmp).
+func isExcluded(rel string, patterns []string) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		partial := strings.Join(parts[:i+1], "/")
+		for _, pattern := range patterns {
+			pattern = strings.Trim(pattern, "/")
+			name := parts[i]
+			if strings.Contains(pattern, "/") {
+				name = partial
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// open starts the copy pod of a volume endpoint. The returned function stops it.
+func (s *syncSide) open(ctx context.Context, podPrefix, role string, readOnly bool) (func(), error) {
+	if s.IsLocal() {
+		s.dir = s.LocalPath
+		return func() {}, nil
+	}
+
+	pvc, isOS, err := checkCopyVolume(ctx, s.c, s.NodeName, s.VolumeName, role)
+	if err != nil {
+		return nil, err
+	}
+	s.podName = podPrefix + pvc
+	s.dir = path.Join("/data", path.Clean("/"+s.Path))
+	return startCopyPod(ctx, s.c, s.podName, s.NodeName, s.VolumeName, readOnly, isOS)
+}
+
+// manifest lists the files below the synced directory, or returns nil if it doesn't exist
+func (s *syncSide) manifest(ctx context.Context, exclude []string, checksum bool) (syncManifest, error) {
+	if s.IsLocal() {
+		return localManifest(s.dir, exclude, checksum)
+	}
+
+	// %f is the raw mode in hex, which includes the file type
+	var stdout, stderr bytes.Buffer
+	script := `[ -d "$1" ] || exit 0; echo exists; cd "$1" && find . -mindepth 1 -exec stat -c '%s %Y %f %n' {} +`
+	if err := execInPod(ctx, s.c, s.podName, []string{"sh", "-c", script, "sh", s.dir}, nil, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return nil, nil // Directory does not exist
+	}
+
+	m := syncManifest{}
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		rel := strings.TrimPrefix(fields[3], "./")
+		if isExcluded(rel, exclude) {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[0], 10, 64)
+		modTime, _ := strconv.ParseInt(fields[1], 10, 64)
+		raw, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		mode := fs.FileMode(raw & 0o777)
+		switch raw & 0o170000 {
+		case 0o100000: // Regular file
+		case 0o040000:
+			mode |= fs.ModeDir
+		case 0o120000:
+			mode |= fs.ModeSymlink
+		default:
+			continue // Devices, FIFOs, sockets and overlay whiteouts
+		}
+		m[rel] = syncEntry{mode: mode, size: size, modTime: modTime}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if checksum {
+		sums, err := remoteChecksums(ctx, s.c, s.podName, s.dir)
+		if err != nil {
+			return nil, err
+		}
+		for rel, sum := range sums {
+			if e, ok := m[rel]; ok {
+				e.checksum = sum
+				m[rel] = e
+			}
+		}
+	}
+	return m, nil
+}
+
+// localManifest lists the files below a local directory, or returns nil if it doesn't exist
+func localManifest(dir string, exclude []string, checksum bool) (syncManifest, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err == nil {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		return nil, err
+	}
+
+	m := syncManifest{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isExcluded(rel, exclude) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := info.Mode()
+		if !mode.IsRegular() && !mode.IsDir() && mode&fs.ModeSymlink == 0 {
+			return nil
+		}
+		e := syncEntry{mode: mode.Type() | mode.Perm(), size: info.Size(), modTime: info.ModTime().Unix()}
+		if mode&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			e.size = int64(len(link))
+		}
+		if checksum && mode.IsRegular() {
+			if e.checksum, err = fileChecksum(p); err != nil {
+				return err
+			}
+		}
+		m[rel] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fileChecksum returns the hex SHA-256 of a local file
+func fileChecksum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// send writes a tar archive of the given paths (not recursive) to w
+func (s *syncSide) send(ctx context.Context, paths []string, w io.Writer) error {
+	if s.IsLocal() {
+		tw := tar.NewWriter(w)
+		for _, rel := range paths {
+			p := filepath.Join(s.dir, filepath.FromSlash(rel))
+			info, err := os.Lstat(p)
+			if err != nil {
+				return err
+			}
+			if err := writeTarEntry(tw, p, rel, info); err != nil {
+				return err
+			}
+		}
+		return tw.Close()
+	}
+
+	// The file list is read from stdin
+	var stderr bytes.Buffer
+	list := strings.NewReader(strings.Join(paths, "\n") + "\n")
+	err := execInPod(ctx, s.c, s.podName, []string{"tar", "cf", "-", "--no-recursion", "-C", s.dir, "-T", "-"}, list, w, &stderr)
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+// receive extracts a tar archive from r into the synced directory. Archives
+// from the local machine are extracted as the volume's user (not the local uid).
+func (s *syncSide) receive(ctx context.Context, r io.Reader, fromLocal bool) error {
+	if s.IsLocal() {
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return err
+		}
+		return extractLocalTar(r, s.dir, ".", false)
+	}
+
+	script := `mkdir -p "$1" && tar -x -f - -C "$1"`
+	if fromLocal {
+		script = `mkdir -p "$1" && tar -x -o -f - -C "$1"`
+	}
+	var stderr bytes.Buffer
+	err := execInPod(ctx, s.c, s.podName, []string{"sh", "-c", script, "sh", s.dir}, r, nil, &stderr)
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+// remove deletes the given paths (recursively) below the synced directory
+func (s *syncSide) remove(ctx context.Context, paths []string) error {
+	if s.IsLocal() {
+		root, err := os.OpenRoot(s.dir)
+		if err != nil {
+			return err
+		}
+		defer root.Close()
+		for _, rel := range paths {
+			if err := root.RemoveAll(filepath.FromSlash(rel)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var stderr bytes.Buffer
+	list := strings.NewReader(strings.Join(paths, "\n") + "\n")
+	script := `cd "$1" && while IFS= read -r f; do rm -rf -- "./$f" || exit 1; done`
+	err := execInPod(ctx, s.c, s.podName, []string{"sh", "-c", script, "sh", s.dir}, list, nil, &stderr)
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
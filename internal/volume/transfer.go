@@ -0,0 +1,302 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Compression methods for cross-node transfers
+const (
+	CompressNone = ""
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// zstdCopyImage is used for copy pods when compressing with zstd, which busybox
+// lacks. Its zstd comes with the image, as pacman needs it, but it has no nc,
+// so zstd streams can't be transferred directly.
+const zstdCopyImage = "archlinux:base"
+
+// ParseBandwidth parses a bandwidth limit in bytes per second, such as 10M or 512Ki
+func ParseBandwidth(s string) (int64, error) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil || q.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth limit %q (bytes per second, e.g. 10M or 512Ki)", s)
+	}
+	return q.Value(), nil
+}
+
+// ValidateCompression returns an error if compress is not a supported compression method
+func ValidateCompression(compress string) error {
+	switch compress {
+	case CompressNone, CompressGzip, CompressZstd:
+		return nil
+	}
+	return fmt.Errorf("invalid compression %q (must be gzip or zstd)", compress)
+}
+
+// transferOptions controls how a cross-node tar stream is relayed
+type transferOptions struct {
+	verify   bool   // Hash the stream (see checkStreamHash) and compare file checksums
+	compress string // Compression done in the pods: "", gzip or zstd
+	bwLimit  int64  // Bytes per second (0 = unlimited); needs the stream to be relayed
+	relay    bool   // Relay the stream through sgs without trying a direct transfer
+}
+
+// transferOptions returns the options for relaying the stream of a cross-node copy
+func (opts CopyOptions) transferOptions() transferOptions {
+	return transferOptions{verify: opts.Verify, compress: opts.Compress, bwLimit: opts.BwLimit, relay: opts.Relay}
+}
+
+// transferStream streams the contents of srcDir in srcPod into dstDir in
+// dstPod. The destination pod pulls the stream directly from the source pod
+// when pod networking allows it; otherwise sgs relays it.
+func transferStream(ctx context.Context, c *client.Client, srcPod, srcDir, dstPod, dstDir string, opts transferOptions) error {
+	switch {
+	case opts.relay || opts.bwLimit > 0:
+	case opts.compress == CompressZstd:
+		fmt.Println("  Direct transfer unavailable (the zstd copy image has no nc), relaying through sgs")
+	default:
+		err := directStream(ctx, c, srcPod, srcDir, dstPod, dstDir, opts)
+		if !errors.Is(err, errNoPodNetwork) {
+			return err
+		}
+		fmt.Printf("  Direct transfer unavailable (%v), relaying through sgs\n", err)
+	}
+	return relayStream(ctx, c, srcPod, srcDir, dstPod, dstDir, opts)
+}
+
+// relayStream streams the contents of srcDir in srcPod into dstDir in dstPod
+// through the client, which shows progress and applies the bandwidth limit
+func relayStream(ctx context.Context, c *client.Client, srcPod, srcDir, dstPod, dstDir string, opts transferOptions) error {
+	fmt.Println("  Streaming data between nodes (relayed through sgs)...")
+
+	// Use a pipe to connect tar output to tar input with progress tracking
+	pr, pw := io.Pipe()
+	progress := opts.newProgress(ctx, c, pw, srcPod, srcDir)
+
+	// Capture stderr for error messages
+	var srcStderr, dstStderr, dstStdout bytes.Buffer
+	errChan := make(chan error, 2)
+
+	// Source: tar cf - -C <srcDir> .
+	go func() {
+		defer pw.Close()
+		err := execInPod(ctx, c, srcPod, archiveCommand(srcDir, opts.compress), nil, progress, &srcStderr)
+		if err != nil && srcStderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, srcStderr.String())
+		}
+		errChan <- err
+	}()
+
+	// Destination: tar xf - -C <dstDir>
+	go func() {
+		err := execInPod(ctx, c, dstPod, extractCommand(dstDir, opts.compress, opts.verify), pr, &dstStdout, &dstStderr)
+		if err != nil && dstStderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, dstStderr.String())
+		}
+		errChan <- err
+	}()
+
+	// Wait for both to complete
+	for i := 0; i < 2; i++ {
+		if err := <-errChan; err != nil {
+			return fmt.Errorf("copy stream failed: %w", err)
+		}
+	}
+
+	// Print final progress
+	fmt.Printf("\r  %-72s\n", progress.summary())
+
+	if opts.verify {
+		return checkStreamHash(hex.EncodeToString(progress.hash.Sum(nil)), dstStdout.String())
+	}
+	return nil
+}
+
+// newProgress returns the progressWriter for relaying a tar stream of srcDir
+// in srcPod. The ETA is shown for uncompressed streams, whose size is known.
+func (opts transferOptions) newProgress(ctx context.Context, c *client.Client, w io.Writer, srcPod, srcDir string) *progressWriter {
+	var total int64
+	if opts.compress == CompressNone {
+		total = remoteSize(ctx, c, srcPod, srcDir)
+	}
+	progress := newTransferProgress(w, total)
+	progress.limit = opts.bwLimit
+	if opts.verify {
+		progress.hash = sha256.New()
+	}
+	return progress
+}
+
+// progressInterval is how often transfer progress is redrawn
+const progressInterval = 250 * time.Millisecond
+
+// newTransferProgress returns a progressWriter that prints the bytes
+// transferred and the throughput, and the percentage and estimated time
+// left if total (the expected number of bytes) is known
+func newTransferProgress(w io.Writer, total int64) *progressWriter {
+	var progressMu sync.Mutex
+	var lastPrinted time.Time
+	pw := &progressWriter{writer: w, start: time.Now()}
+	pw.onWrite = func(written int64) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		// Redraw a few times per second to avoid too frequent updates
+		if time.Since(lastPrinted) < progressInterval {
+			return
+		}
+		lastPrinted = time.Now()
+
+		elapsed := time.Since(pw.start)
+		rate := float64(written) / elapsed.Seconds()
+		line := fmt.Sprintf("Transferred: %s, %s/s", FormatBytes(written), FormatBytes(int64(rate)))
+		if total > 0 && rate > 0 {
+			// Headers make the stream slightly larger than the data, so stop short of 100%
+			percent := min(written*100/total, 99)
+			eta := time.Duration(float64(max(total-written, 0))/rate) * time.Second
+			line = fmt.Sprintf("Transferred: %s / %s (%d%%), %s/s, ETA %s",
+				FormatBytes(written), FormatBytes(total), percent, FormatBytes(int64(rate)), eta.Round(time.Second))
+		}
+		fmt.Printf("\r  %-72s", line)
+	}
+	return pw
+}
+
+// summary returns the final bytes transferred, duration and average throughput
+func (pw *progressWriter) summary() string {
+	elapsed := time.Since(pw.start)
+	rate := float64(pw.written) / max(elapsed.Seconds(), 0.001)
+	return fmt.Sprintf("Transferred: %s in %s (%s/s)", FormatBytes(pw.written), elapsed.Round(time.Second), FormatBytes(int64(rate)))
+}
+
+// update sets the bytes transferred when they are counted outside of Write
+func (pw *progressWriter) update(written int64) {
+	pw.written = written
+	if pw.onWrite != nil {
+		pw.onWrite(written)
+	}
+}
+
+// throttle sleeps as needed to keep the average rate at or below limit bytes per second
+func (pw *progressWriter) throttle() {
+	if pw.limit <= 0 {
+		return
+	}
+	expected := time.Duration(float64(pw.written) / float64(pw.limit) * float64(time.Second))
+	if wait := expected - time.Since(pw.start); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// compressCommand returns the shell command that compresses stdin, or "" for no compression
+func compressCommand(compress string) string {
+	switch compress {
+	case CompressGzip:
+		return "gzip -c"
+	case CompressZstd:
+		return "zstd -c -q -T0"
+	}
+	return ""
+}
+
+// decompressCommand returns the shell command that decompresses stdin, or "" for no compression
+func decompressCommand(compress string) string {
+	switch compress {
+	case CompressGzip:
+		return "gzip -dc"
+	case CompressZstd:
+		return "zstd -dc -q"
+	}
+	return ""
+}
+
+// archiveCommand returns the command that writes a tar stream of the
+// contents of dir to stdout, compressed in the pod if requested
+func archiveCommand(dir, compress string) []string {
+	c := compressCommand(compress)
+	if c == "" {
+		return []string{"tar", "cf", "-", "-C", dir, "."}
+	}
+	return []string{"sh", "-c", `set -o pipefail; tar cf - -C "$1" . | ` + c, "sh", dir}
+}
+
+// extractCommand returns the command that extracts a tar stream from stdin
+// into dir, decompressing it first if needed. With verify, it also prints the
+// SHA-256 of all bytes received (see checkStreamHash).
+func extractCommand(dir, compress string, verify bool) []string {
+	return []string{"sh", "-c", extractScript(compress, verify), "sh", dir}
+}
+
+// extractScript returns the shell script of extractCommand, which extracts
+// into the directory given as "$1"
+func extractScript(compress string, verify bool) string {
+	d := decompressCommand(compress)
+	if d == "" && !verify {
+		return `tar xf - -C "$1"`
+	}
+
+	// Input after the end of the archive is drained, so that commands
+	// upstream (and the hash) always see the whole stream
+	pipeline := `{ tar xf - -C "$1"; rc=$?; cat > /dev/null; exit $rc; }`
+	if d != "" {
+		pipeline = d + " | " + pipeline
+	}
+	if !verify {
+		return "set -o pipefail; " + pipeline
+	}
+
+	return `set -o pipefail; f=/tmp/sgs-stream-$$; mkfifo "$f" || exit 1
+sha256sum < "$f" > "$f.sum" &
+tee "$f" | ` + pipeline + `
+rc=$?; wait; cut -d' ' -f1 "$f.sum"; rm -f "$f" "$f.sum"; exit $rc`
+}
+
+// useCompression adapts a copy pod so that it can run the compressor
+func useCompression(pod *corev1.Pod, compress string) {
+	if compress == CompressZstd {
+		pod.Spec.Containers[0].Image = zstdCopyImage
+	}
+}
+
+// checkCompression returns an error if a copy pod adapted by useCompression
+// can't run the compressor
+func checkCompression(ctx context.Context, c *client.Client, podName, compress string) error {
+	if compress != CompressZstd {
+		return nil
+	}
+	if err := execInPod(ctx, c, podName, []string{"sh", "-c", "command -v zstd"}, nil, io.Discard, io.Discard); err != nil {
+		return fmt.Errorf("zstd is not available in the copy pod (%s); use --compress gzip instead: %w", zstdCopyImage, err)
+	}
+	return nil
+}
+
+// remoteSize returns the disk usage of dir in a pod in bytes, or 0 if it can't be determined
+func remoteSize(ctx context.Context, c *client.Client, podName, dir string) int64 {
+	var stdout bytes.Buffer
+	if err := execInPod(ctx, c, podName, []string{"du", "-sk", dir}, nil, &stdout, io.Discard); err != nil {
+		return 0
+	}
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return 0
+	}
+	kb, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return kb * 1024
+}
@@ -0,0 +1,199 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Sources of volume usage
+const (
+	UsageSourceKubelet = "kubelet" // Volume stats of the node, for volumes mounted by a pod
+	UsageSourceDF      = "df"      // df in a helper pod
+)
+
+// VolumeUsage is the space used and available on a volume's file system
+type VolumeUsage struct {
+	UsedBytes      int64  `json:"usedBytes" yaml:"usedBytes"`
+	AvailableBytes int64  `json:"availableBytes" yaml:"availableBytes"`
+	CapacityBytes  int64  `json:"capacityBytes" yaml:"capacityBytes"`
+	Source         string `json:"source" yaml:"source"` // kubelet or df
+}
+
+// Percent returns the used space as a percentage of the space usable by
+// the user (like df, which leaves out blocks reserved for root)
+func (u *VolumeUsage) Percent() int {
+	usable := u.UsedBytes + u.AvailableBytes
+	if usable <= 0 {
+		return 0
+	}
+	return int(u.UsedBytes * 100 / usable)
+}
+
+// statsSummary is the part of the kubelet stats summary (/stats/summary) used here
+type statsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			PVCRef *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+			UsedBytes      *uint64 `json:"usedBytes"`
+			AvailableBytes *uint64 `json:"availableBytes"`
+			CapacityBytes  *uint64 `json:"capacityBytes"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// AddUsage sets the Usage of volumes from the volume stats of their nodes.
+// Only volumes mounted by a running pod have stats. With df, the usage of
+// the other volumes (if bound) is measured with helper pods, which is slower.
+// Volumes whose usage can't be determined keep a nil Usage.
+func AddUsage(ctx context.Context, c *client.Client, volumes []VolumeInfo, df bool) {
+	stats := make(map[string]map[string]VolumeUsage) // node -> PVC name -> usage
+	forbidden := false
+	for i := range volumes {
+		v := &volumes[i]
+		if _, ok := stats[v.NodeName]; !ok && !forbidden {
+			// Reading node stats needs the nodes/proxy permission; without it, only df works
+			var err error
+			stats[v.NodeName], err = nodeVolumeStats(ctx, c, v.NodeName)
+			// The permission is the same for every node, so don't ask again
+			forbidden = errors.IsForbidden(err)
+		}
+		if u, ok := stats[v.NodeName][PVCName(v.NodeName, v.VolumeName)]; ok {
+			v.Usage = &u
+		}
+	}
+
+	if !df {
+		return
+	}
+	var missing []*VolumeInfo
+	for i := range volumes {
+		// Unbound volumes would be provisioned by mounting them, so they are skipped
+		if volumes[i].Usage == nil && volumes[i].Status != "Pending" && volumes[i].Status != "Lost" {
+			missing = append(missing, &volumes[i])
+		}
+	}
+	if len(missing) > 0 {
+		dfUsage(ctx, c, missing)
+	}
+}
+
+// nodeVolumeStats returns the usage of the PVCs in the current namespace
+// that are mounted by pods on a node, keyed by PVC name
+func nodeVolumeStats(ctx context.Context, c *client.Client, nodeName string) (map[string]VolumeUsage, error) {
+	data, err := c.Clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats", "summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary statsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse volume stats: %w", err)
+	}
+
+	usage := make(map[string]VolumeUsage)
+	for _, pod := range summary.Pods {
+		for _, vol := range pod.Volumes {
+			if vol.PVCRef == nil || vol.PVCRef.Namespace != c.Namespace ||
+				vol.UsedBytes == nil || vol.AvailableBytes == nil || vol.CapacityBytes == nil {
+				continue
+			}
+			usage[vol.PVCRef.Name] = VolumeUsage{
+				UsedBytes:      int64(*vol.UsedBytes),
+				AvailableBytes: int64(*vol.AvailableBytes),
+				CapacityBytes:  int64(*vol.CapacityBytes),
+				Source:         UsageSourceKubelet,
+			}
+		}
+	}
+	return usage, nil
+}
+
+// dfUsage measures the usage of volumes by running df in a helper pod per
+// volume. The pods run at the same time and are deleted when done.
+func dfUsage(ctx context.Context, c *client.Client, volumes []*VolumeInfo) {
+	fmt.Fprintf(os.Stderr, "Measuring usage of %d volumes with helper pods...", len(volumes))
+
+	podNames := make([]string, len(volumes))
+	for i, v := range volumes {
+		pvc := PVCName(v.NodeName, v.VolumeName)
+		pod := createCopyPod("usage-"+pvc, v.NodeName, pvc, c.Namespace, true, false)
+		if _, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err == nil {
+			podNames[i] = pod.Name
+		}
+	}
+
+	deletePods := func(ctx context.Context) {
+		for _, name := range podNames {
+			if name != "" {
+				_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			}
+		}
+	}
+	// Register cleanup for interrupt handling
+	cleanup.Register(func(cleanupCtx context.Context) {
+		fmt.Fprint(os.Stderr, "  Cleaning up helper pods...")
+		deletePods(cleanupCtx)
+		fmt.Fprintln(os.Stderr, " done")
+	})
+	defer func() {
+		cleanup.Unregister()
+		deletePods(context.Background())
+	}()
+
+	for i, v := range volumes {
+		if podNames[i] == "" || waitForPodRunning(ctx, c, podNames[i], 2*time.Minute) != nil {
+			continue
+		}
+		var stdout bytes.Buffer
+		if err := execInPod(ctx, c, podNames[i], []string{"df", "-P", "-k", "/data"}, nil, &stdout, nil); err != nil {
+			continue
+		}
+		if u, ok := parseDF(stdout.String()); ok {
+			v.Usage = &u
+		}
+	}
+	fmt.Fprintln(os.Stderr, " done")
+}
+
+// parseDF parses the output of df -P -k for a single file system
+func parseDF(out string) (VolumeUsage, bool) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return VolumeUsage{}, false
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return VolumeUsage{}, false
+	}
+	capacity, err1 := strconv.ParseInt(fields[1], 10, 64)
+	used, err2 := strconv.ParseInt(fields[2], 10, 64)
+	avail, err3 := strconv.ParseInt(fields[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return VolumeUsage{}, false
+	}
+	return VolumeUsage{
+		UsedBytes:      used * 1024,
+		AvailableBytes: avail * 1024,
+		CapacityBytes:  capacity * 1024,
+		Source:         UsageSourceDF,
+	}, true
+}
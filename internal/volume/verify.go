@@ -0,0 +1,128 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// VerifyError reports files that differ between the source and destination of a copy
+type VerifyError struct {
+	Mismatches []string // One line per missing or differing file
+	Files      int      // Number of files checked
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("verification failed: %d of %d files differ", len(e.Mismatches), e.Files)
+}
+
+// checkStreamHash compares the hex SHA-256 of the stream that was sent with
+// the hash printed by the destination pod (see extractCommand)
+func checkStreamHash(sentSum, received string) error {
+	sentSum = strings.TrimSpace(sentSum)
+	received = strings.TrimSpace(received)
+	if received != sentSum {
+		return fmt.Errorf("stream checksum mismatch: sent %s, destination received %s", sentSum, received)
+	}
+	fmt.Printf("  Stream SHA-256: %s\n", sentSum)
+	return nil
+}
+
+// verifyFiles compares the checksums of all files below srcDir in srcPod with
+// the same files below dstDir in dstPod. Extra files in dstDir are ignored.
+func verifyFiles(ctx context.Context, c *client.Client, srcPod, srcDir, dstPod, dstDir string) error {
+	fmt.Print("  Verifying file checksums...")
+	srcSums, err := remoteChecksums(ctx, c, srcPod, srcDir)
+	if err != nil {
+		fmt.Println(" failed")
+		return fmt.Errorf("failed to checksum source files: %w", err)
+	}
+	dstSums, err := remoteChecksums(ctx, c, dstPod, dstDir)
+	if err != nil {
+		fmt.Println(" failed")
+		return fmt.Errorf("failed to checksum destination files: %w", err)
+	}
+
+	mismatches := compareChecksums(srcSums, dstSums)
+	if len(mismatches) == 0 {
+		fmt.Printf(" done (%d files match)\n", len(srcSums))
+		return nil
+	}
+
+	fmt.Println(" failed")
+	for _, m := range mismatches {
+		fmt.Printf("    %s\n", m)
+	}
+	return &VerifyError{Mismatches: mismatches, Files: len(srcSums)}
+}
+
+// remoteChecksums returns the SHA-256 of each regular file below dir in a pod,
+// keyed by slash-separated path relative to dir
+func remoteChecksums(ctx context.Context, c *client.Client, podName, dir string) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+	script := `cd "$1" && find . -type f -exec sha256sum {} +`
+	if err := execInPod(ctx, c, podName, []string{"sh", "-c", script, "sh", dir}, nil, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	sums := make(map[string]string)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		sums[strings.TrimPrefix(name, "./")] = sum
+	}
+	return sums, nil
+}
+
+// compareChecksums returns the files of src that are missing or differ in dst, sorted by path
+func compareChecksums(src, dst map[string]string) []string {
+	paths := make([]string, 0, len(src))
+	for p := range src {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var mismatches []string
+	for _, p := range paths {
+		switch sum, ok := dst[p]; {
+		case !ok:
+			mismatches = append(mismatches, "missing:  "+p)
+		case sum != src[p]:
+			mismatches = append(mismatches, "differs:  "+p)
+		}
+	}
+	return mismatches
+}
+
+// verifyInPodScript returns a shell snippet for copy pods that checks that every
+// file below srcDir/name has the same checksum below dstDir/name. It lists the
+// files that differ and exits with code 3 if any do.
+func verifyInPodScript(srcDir, name, dstDir string) string {
+	return fmt.Sprintf(
+		"cd %s && find %s -type f -exec sha256sum {} + > /tmp/sgs-sums && cd %s && "+
+			"if sha256sum -c /tmp/sgs-sums > /tmp/sgs-check 2>&1; then echo 'Verified '$(wc -l < /tmp/sgs-sums)' files'; "+
+			"else echo 'VERIFY FAILED:'; grep -v ': OK$' /tmp/sgs-check; exit 3; fi",
+		shellQuote(srcDir), shellQuote("./"+name), shellQuote(dstDir))
+}
+
+// shellQuote quotes s for use as a single word in a shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// withCopyPodLogs adds the last lines of a failed copy pod's output to err
+func withCopyPodLogs(c *client.Client, podName string, err error) error {
+	tail := int64(20)
+	logs, logErr := c.Clientset.CoreV1().Pods(c.Namespace).GetLogs(podName, &corev1.PodLogOptions{TailLines: &tail}).DoRaw(context.Background())
+	if logErr != nil || len(bytes.TrimSpace(logs)) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w:\n%s", err, strings.TrimRight(string(logs), "\n"))
+}
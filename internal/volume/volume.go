@@ -5,10 +5,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path"
+	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/bacchus-snu/sgs-cli/internal/cleanup"
@@ -47,13 +49,15 @@ func workspaceExists(ctx context.Context, c *client.Client, name string) bool {
 
 // VolumeInfo represents information about an SGS volume
 type VolumeInfo struct {
-	NodeName   string
-	VolumeName string
-	Status     string
-	Size       string
-	Image      string // OS image from annotation (empty for normal volumes)
-	Age        string
-	IsOSVolume bool
+	NodeName   string       `json:"nodeName" yaml:"nodeName"`
+	VolumeName string       `json:"volumeName" yaml:"volumeName"`
+	Status     string       `json:"status" yaml:"status"`
+	Size       string       `json:"size" yaml:"size"`
+	Image      string       `json:"image,omitempty" yaml:"image,omitempty"` // OS image from annotation (empty for normal volumes)
+	Age        string       `json:"age" yaml:"age"`
+	CreatedAt  time.Time    `json:"createdAt" yaml:"createdAt"`
+	IsOSVolume bool         `json:"isOSVolume" yaml:"isOSVolume"`
+	Usage      *VolumeUsage `json:"usage,omitempty" yaml:"usage,omitempty"` // Set by AddUsage when known
 }
 
 // CreateOptions holds options for creating a volume
@@ -62,25 +66,31 @@ type CreateOptions struct {
 	VolumeName string
 	Size       string
 	Image      string // If empty, creates a normal volume (no pod); if set, creates OS volume
+	Setup      string // Script run on a new OS volume before it is ready (see runSetup)
 }
 
 // EditOptions holds options for editing a volume
 type EditOptions struct {
 	NodeName   string
 	VolumeName string
-	Mounts     []MountOption // Additional volumes to mount
+	Mounts     []MountOption     // Additional volumes to mount
+	Env        map[string]string // Environment variables
+	Secrets    []SecretOption    // SGS secrets to inject
 }
 
 // RunOptions holds options for running a volume with GPU
 type RunOptions struct {
 	NodeName   string
 	VolumeName string
-	GPUs       int           // Number of GPUs
-	GPUMem     int64         // GPU memory in MiB (HAMi)
-	Command    []string      // Command to run (optional, interactive if empty)
-	Mounts     []MountOption // Additional volumes to mount
-	PinCPU     int64         // Pinned CPU cores (0 = no pinning)
-	PinMem     int64         // Pinned memory in bytes (0 = no pinning)
+	GPUs       int               // Number of GPUs
+	GPUMem     int64             // GPU memory in MiB (HAMi)
+	GPUType    string            // Only use GPUs whose type contains this (HAMi, optional)
+	Command    []string          // Command to run (optional, interactive if empty)
+	Mounts     []MountOption     // Additional volumes to mount
+	PinCPU     int64             // Pinned CPU cores (0 = no pinning)
+	PinMem     int64             // Pinned memory in bytes (0 = no pinning)
+	Env        map[string]string // Environment variables
+	Secrets    []SecretOption    // SGS secrets to inject
 }
 
 // MountOption represents a volume mount
@@ -90,6 +100,12 @@ type MountOption struct {
 	IsOSVolume   bool   // True if source is OS volume (needs subPath for mounting)
 }
 
+// SecretOption injects an SGS secret (see the secret package) into a session
+type SecretOption struct {
+	Name      string // Secret name
+	MountPath string // Directory to mount the keys as files in (empty = environment variables)
+}
+
 // ValidateMounts checks that all mounts exist and marks which are OS volumes.
 // OS volumes are allowed to be mounted - they will use subPath: "upper" to expose
 // only the user's filesystem (hiding overlayfs internals like work/, merged/).
@@ -99,7 +115,7 @@ func ValidateMounts(ctx context.Context, c *client.Client, mounts []MountOption)
 		// Get PVC to check if it exists and if it's an OS volume
 		pvc, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, m.SourceVolume, metav1.GetOptions{})
 		if err != nil {
-			return nil, fmt.Errorf("mount volume %q not found", m.SourceVolume)
+			return nil, fmt.Errorf("mount volume %q not found: %w", m.SourceVolume, err)
 		}
 
 		result[i] = MountOption{
@@ -111,18 +127,25 @@ func ValidateMounts(ctx context.Context, c *client.Client, mounts []MountOption)
 	return result, nil
 }
 
-// LogsOptions holds options for getting logs
-type LogsOptions struct {
-	Follow bool
-	Tail   int64
-}
-
-// pvcName returns the PVC name for a volume
+// PVCName returns the PVC name for a volume
 // Format: <node>-<volume> to allow same volume name on different nodes
-func pvcName(nodeName, volumeName string) string {
+func PVCName(nodeName, volumeName string) string {
 	return nodeName + "-" + volumeName
 }
 
+// maxNameLength is the longest PVC name and label value Kubernetes accepts
+const maxNameLength = 63
+
+// CheckNameLength checks that the PVC name <node>-<volume> of a volume fits
+// the length Kubernetes accepts
+func CheckNameLength(nodeName, volumeName string) error {
+	if n := len(PVCName(nodeName, volumeName)); n > maxNameLength {
+		return fmt.Errorf("volume name %q is too long for node %s: %s must be at most %d characters, is %d",
+			volumeName, nodeName, PVCName(nodeName, volumeName), maxNameLength, n)
+	}
+	return nil
+}
+
 // ParseVolumePath parses a user input "node/volume" into node and volume names
 func ParseVolumePath(path string) (nodeName, volumeName string, err error) {
 	parts := strings.SplitN(path, "/", 2)
@@ -201,63 +224,59 @@ func List(ctx context.Context, c *client.Client) ([]VolumeInfo, error) {
 	}
 
 	var volumes []VolumeInfo
-	for _, pvc := range pvcs.Items {
-		// Get node from selected-node annotation, fallback to label
-		nodeName := pvc.Annotations[sgs.AnnotationSelectedNode]
-		if nodeName == "" {
-			nodeName = pvc.Labels[sgs.LabelNodeName]
-		}
+	for i := range pvcs.Items {
+		volumes = append(volumes, pvcToVolumeInfo(ctx, c, &pvcs.Items[i]))
+	}
 
-		// Get volume name from label (PVC name is <node>-<volume>)
-		volumeName := pvc.Labels[sgs.LabelVolumeName]
-		if volumeName == "" {
-			// Fallback: try to extract from PVC name (for backwards compatibility)
-			volumeName = pvc.Name
-		}
+	return volumes, nil
+}
 
-		// Check if this is an OS volume (has image annotation)
-		osImage := pvc.Annotations[sgs.AnnotationOSImage]
-		isOSVolume := osImage != ""
+// pvcToVolumeInfo converts a PVC to VolumeInfo, using the session pod status if one exists
+func pvcToVolumeInfo(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim) VolumeInfo {
+	// Get node from selected-node annotation, fallback to label
+	nodeName := pvc.Annotations[sgs.AnnotationSelectedNode]
+	if nodeName == "" {
+		nodeName = pvc.Labels[sgs.LabelNodeName]
+	}
 
-		// Check if there's an associated session pod
-		pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{})
-		status := string(pvc.Status.Phase) // Default to PVC status (Bound, Pending, etc.)
-		if err == nil {
-			// Pod exists, use pod status
-			status = string(pod.Status.Phase)
-		}
+	// Get volume name from label (PVC name is <node>-<volume>)
+	volumeName := pvc.Labels[sgs.LabelVolumeName]
+	if volumeName == "" {
+		// Fallback: try to extract from PVC name (for backwards compatibility)
+		volumeName = pvc.Name
+	}
 
-		size := "N/A"
-		if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
-			size = storage.String()
-		}
+	// Check if this is an OS volume (has image annotation)
+	osImage := pvc.Annotations[sgs.AnnotationOSImage]
+	isOSVolume := osImage != ""
 
-		age := formatAge(time.Since(pvc.CreationTimestamp.Time))
+	// Check if there's an associated session pod
+	pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{})
+	status := string(pvc.Status.Phase) // Default to PVC status (Bound, Pending, etc.)
+	if err == nil {
+		// Pod exists, use pod status
+		status = string(pod.Status.Phase)
+	} else if setup := setupStatus(ctx, c, pvc); setup != "" {
+		status = setup
+	}
 
-		volumes = append(volumes, VolumeInfo{
-			NodeName:   nodeName,
-			VolumeName: volumeName,
-			Status:     status,
-			Size:       size,
-			Image:      osImage,
-			Age:        age,
-			IsOSVolume: isOSVolume,
-		})
+	size := "N/A"
+	if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		size = storage.String()
 	}
 
-	return volumes, nil
-}
+	age := sgs.FormatAge(time.Since(pvc.CreationTimestamp.Time))
 
-// formatAge formats a duration into a human-readable age string
-func formatAge(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	} else if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	} else if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
+	return VolumeInfo{
+		NodeName:   nodeName,
+		VolumeName: volumeName,
+		Status:     status,
+		Size:       size,
+		Image:      osImage,
+		Age:        age,
+		CreatedAt:  pvc.CreationTimestamp.Time,
+		IsOSVolume: isOSVolume,
 	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
 }
 
 // ListByNode returns all volumes on a specific node
@@ -280,7 +299,7 @@ func ListByNode(ctx context.Context, c *client.Client, nodeName string) ([]Volum
 // Get returns information about a specific volume by node and name
 func Get(ctx context.Context, c *client.Client, nodeName, volumeName string) (*VolumeInfo, error) {
 	pvc, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaim, error) {
-		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, pvcName(nodeName, volumeName), metav1.GetOptions{})
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, PVCName(nodeName, volumeName), metav1.GetOptions{})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("volume not found: %w", err)
@@ -292,11 +311,13 @@ func Get(ctx context.Context, c *client.Client, nodeName, volumeName string) (*V
 
 	// Check if there's an associated pod
 	pod, err := client.RetryWithContext(ctx, func() (*corev1.Pod, error) {
-		return c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, pvcName(nodeName, volumeName), metav1.GetOptions{})
+		return c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, PVCName(nodeName, volumeName), metav1.GetOptions{})
 	})
 	status := string(pvc.Status.Phase)
 	if err == nil {
 		status = string(pod.Status.Phase)
+	} else if setup := setupStatus(ctx, c, pvc); setup != "" {
+		status = setup
 	}
 
 	size := "N/A"
@@ -304,7 +325,7 @@ func Get(ctx context.Context, c *client.Client, nodeName, volumeName string) (*V
 		size = storage.String()
 	}
 
-	age := formatAge(time.Since(pvc.CreationTimestamp.Time))
+	age := sgs.FormatAge(time.Since(pvc.CreationTimestamp.Time))
 
 	return &VolumeInfo{
 		NodeName:   nodeName,
@@ -313,6 +334,7 @@ func Get(ctx context.Context, c *client.Client, nodeName, volumeName string) (*V
 		Size:       size,
 		Image:      osImage,
 		Age:        age,
+		CreatedAt:  pvc.CreationTimestamp.Time,
 		IsOSVolume: isOSVolume,
 	}, nil
 }
@@ -326,7 +348,7 @@ func Create(ctx context.Context, c *client.Client, opts CreateOptions) error {
 		return err
 	}
 
-	name := pvcName(opts.NodeName, opts.VolumeName)
+	name := PVCName(opts.NodeName, opts.VolumeName)
 
 	// Set defaults
 	if opts.Size == "" {
@@ -346,6 +368,10 @@ func Create(ctx context.Context, c *client.Client, opts CreateOptions) error {
 		// OS volume - store image in annotation for runtime wrapper
 		annotations[sgs.AnnotationOSImage] = opts.Image
 	}
+	if opts.Setup != "" {
+		// Not ready until the setup script succeeds
+		annotations[sgs.AnnotationSetup] = SetupRunning
+	}
 
 	// Create PVC
 	pvc := &corev1.PersistentVolumeClaim{
@@ -423,6 +449,10 @@ func Create(ctx context.Context, c *client.Client, opts CreateOptions) error {
 		cleanup.Unregister() // binder pod
 		cleanup.Unregister() // PVC
 		_ = c.Clientset.CoreV1().Pods(c.Namespace).Delete(ctx, podName, metav1.DeleteOptions{})
+
+		if opts.Setup != "" {
+			return runSetup(ctx, c, opts, name)
+		}
 	}
 
 	return nil
@@ -430,48 +460,25 @@ func Create(ctx context.Context, c *client.Client, opts CreateOptions) error {
 
 // waitForBinderPod waits for the binder pod to complete successfully or fail
 func waitForBinderPod(ctx context.Context, c *client.Client, podName string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to get binder pod: %w", err)
-		}
-
+	_, err := WaitForPod(ctx, c, podName, timeout, func(pod *corev1.Pod) (bool, error) {
 		switch pod.Status.Phase {
 		case corev1.PodSucceeded:
-			return nil
+			return true, nil
 		case corev1.PodFailed:
 			// Get reason from container status
 			for _, cs := range pod.Status.ContainerStatuses {
 				if cs.State.Terminated != nil && cs.State.Terminated.Reason != "" {
-					return fmt.Errorf("binder pod failed: %s", cs.State.Terminated.Reason)
+					return false, fmt.Errorf("binder pod failed: %s", cs.State.Terminated.Reason)
 				}
 				if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
-					return fmt.Errorf("binder pod failed: %s - %s", cs.State.Waiting.Reason, cs.State.Waiting.Message)
-				}
-			}
-			return fmt.Errorf("binder pod failed")
-		case corev1.PodPending:
-			// Check for image pull errors
-			for _, cs := range pod.Status.ContainerStatuses {
-				if cs.State.Waiting != nil {
-					if cs.State.Waiting.Reason == "ImagePullBackOff" || cs.State.Waiting.Reason == "ErrImagePull" {
-						return fmt.Errorf("failed to pull image: %s", cs.State.Waiting.Message)
-					}
+					return false, fmt.Errorf("binder pod failed: %s - %s", cs.State.Waiting.Reason, cs.State.Waiting.Message)
 				}
 			}
+			return false, fmt.Errorf("binder pod failed")
 		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(2 * time.Second):
-			// continue polling
-		}
-	}
-
-	return fmt.Errorf("timeout waiting for volume binding")
+		return false, imagePullError(pod)
+	})
+	return timeoutMessage(err, "timeout waiting for volume binding")
 }
 
 // createBinderPodSpec creates a binder pod that initializes the OS volume's overlayfs structure.
@@ -534,7 +541,7 @@ func createBinderPodSpec(pvcName, nodeName, image, namespace string) *corev1.Pod
 
 // GetPVCInfo retrieves PVC info including OS image
 func GetPVCInfo(ctx context.Context, c *client.Client, nodeName, volumeName string) (osImage string, err error) {
-	pvc, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, pvcName(nodeName, volumeName), metav1.GetOptions{})
+	pvc, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, PVCName(nodeName, volumeName), metav1.GetOptions{})
 	if err != nil {
 		return "", fmt.Errorf("volume not found: %w", err)
 	}
@@ -558,15 +565,15 @@ func GetNodeResources(ctx context.Context, c *client.Client, nodeName string) (c
 	return cpuCores, memoryBytes, gpuCount, nil
 }
 
-// sessionPodName returns the pod name for a session
+// SessionPodName returns the pod name for a session
 // Format: <node>-<volume> (one session per volume)
-func sessionPodName(nodeName, volumeName string) string {
+func SessionPodName(nodeName, volumeName string) string {
 	return fmt.Sprintf("%s-%s", nodeName, volumeName)
 }
 
 // GetSessionMode returns the mode of an existing session, or empty string if no session exists
 func GetSessionMode(ctx context.Context, c *client.Client, nodeName, volumeName string) (string, error) {
-	podName := sessionPodName(nodeName, volumeName)
+	podName := SessionPodName(nodeName, volumeName)
 	pod, err := client.RetryWithContext(ctx, func() (*corev1.Pod, error) {
 		return c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
 	})
@@ -623,8 +630,8 @@ func Edit(ctx context.Context, c *client.Client, opts EditOptions) (*EditResult,
 		return nil, err
 	}
 
-	podName := sessionPodName(opts.NodeName, opts.VolumeName)
-	pvc := pvcName(opts.NodeName, opts.VolumeName)
+	podName := SessionPodName(opts.NodeName, opts.VolumeName)
+	pvc := PVCName(opts.NodeName, opts.VolumeName)
 
 	// Get PVC info
 	osImage, err := GetPVCInfo(ctx, c, opts.NodeName, opts.VolumeName)
@@ -636,6 +643,9 @@ func Edit(ctx context.Context, c *client.Client, opts EditOptions) (*EditResult,
 	if osImage == "" {
 		return nil, fmt.Errorf("cannot edit: '%s/%s' is not an OS volume (no image configured)", opts.NodeName, opts.VolumeName)
 	}
+	if err := checkNotSettingUp(ctx, c, opts.NodeName, opts.VolumeName); err != nil {
+		return nil, err
+	}
 
 	// Check if pod already exists
 	existingPod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -656,10 +666,10 @@ func Edit(ctx context.Context, c *client.Client, opts EditOptions) (*EditResult,
 
 	// Create pod with edit mode resources
 	pod := createEditPodSpec(podName, pvc, opts.NodeName, opts.VolumeName, osImage, mounts, c.Namespace)
+	addSessionEnv(pod, opts.Env, opts.Secrets)
 
-	_, err = c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{})
-	if err != nil {
-		return nil, client.FormatK8sError(err, "create", "session", c.Namespace)
+	if err := createSessionPod(ctx, c, pod); err != nil {
+		return nil, err
 	}
 
 	return &EditResult{PodName: podName, Existing: false}, nil
@@ -679,8 +689,8 @@ func Run(ctx context.Context, c *client.Client, opts RunOptions) (*RunResult, er
 		return nil, err
 	}
 
-	podName := sessionPodName(opts.NodeName, opts.VolumeName)
-	pvc := pvcName(opts.NodeName, opts.VolumeName)
+	podName := SessionPodName(opts.NodeName, opts.VolumeName)
+	pvc := PVCName(opts.NodeName, opts.VolumeName)
 
 	// Get PVC info
 	osImage, err := GetPVCInfo(ctx, c, opts.NodeName, opts.VolumeName)
@@ -692,6 +702,9 @@ func Run(ctx context.Context, c *client.Client, opts RunOptions) (*RunResult, er
 	if osImage == "" {
 		return nil, fmt.Errorf("cannot run: '%s/%s' is not an OS volume (no image configured)", opts.NodeName, opts.VolumeName)
 	}
+	if err := checkNotSettingUp(ctx, c, opts.NodeName, opts.VolumeName); err != nil {
+		return nil, err
+	}
 
 	// Check if pod already exists
 	existingPod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -740,10 +753,17 @@ func Run(ctx context.Context, c *client.Client, opts RunOptions) (*RunResult, er
 
 	// Create pod with GPU resources
 	pod := createRunPodSpec(podName, pvc, opts.NodeName, opts.VolumeName, osImage, opts.GPUs, opts.GPUMem, cpuLimit, memLimit, cpuRequest, memRequest, opts.Command, mounts, c.Namespace)
+	addSessionEnv(pod, opts.Env, opts.Secrets)
+	if opts.GPUType != "" {
+		// HAMi only assigns GPUs whose type contains one of the listed values
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations["nvidia.com/use-gputype"] = opts.GPUType
+	}
 
-	_, err = c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{})
-	if err != nil {
-		return nil, client.FormatK8sError(err, "create", "session", c.Namespace)
+	if err := createSessionPod(ctx, c, pod); err != nil {
+		return nil, err
 	}
 
 	return &RunResult{PodName: podName}, nil
@@ -780,6 +800,44 @@ func buildMountSpecs(mounts []MountOption) ([]corev1.VolumeMount, []corev1.Volum
 	return volumeMounts, volumes
 }
 
+// addSessionEnv sets the environment variables of a session's container and
+// injects SGS secrets, either as environment variables or as files (one per
+// key) in a read-only directory. Literal variables take precedence over
+// secrets with the same key.
+func addSessionEnv(pod *corev1.Pod, env map[string]string, secrets []SecretOption) {
+	container := &pod.Spec.Containers[0]
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: env[name]})
+	}
+
+	for i, s := range secrets {
+		if s.MountPath == "" {
+			container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+				SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: s.Name}},
+			})
+			continue
+		}
+		volName := fmt.Sprintf("secret-%d", i)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volName,
+			MountPath: s.MountPath,
+			ReadOnly:  true,
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: volName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: s.Name},
+			},
+		})
+	}
+}
+
 // createEditPodSpec creates an edit pod (root swap enabled via /sgs-os-volume mount path)
 // The runtime wrapper detects the /sgs-os-volume mount path and swaps the container rootfs to the PVC
 func createEditPodSpec(podName, pvcName, nodeName, volumeName, image string, mounts []MountOption, namespace string) *corev1.Pod {
@@ -957,7 +1015,7 @@ func waitForPodDeleted(ctx context.Context, c *client.Client, podName string, ti
 // StopSession stops a session by deleting the pod and waiting for deletion to complete
 // Works for pods in any state (Running, Pending, Failed, Succeeded)
 func StopSession(ctx context.Context, c *client.Client, nodeName, volumeName string) error {
-	podName := sessionPodName(nodeName, volumeName)
+	podName := SessionPodName(nodeName, volumeName)
 
 	err := c.Clientset.CoreV1().Pods(c.Namespace).Delete(ctx, podName, metav1.DeleteOptions{})
 	if err != nil {
@@ -987,8 +1045,8 @@ func Stop(ctx context.Context, c *client.Client, podName string) error {
 
 // Delete deletes a volume (PVC only, session must be deleted first)
 func Delete(ctx context.Context, c *client.Client, nodeName, volumeName string) error {
-	name := pvcName(nodeName, volumeName)
-	podName := sessionPodName(nodeName, volumeName)
+	name := PVCName(nodeName, volumeName)
+	podName := SessionPodName(nodeName, volumeName)
 
 	// Check if there's any session pod (regardless of status) - if so, block deletion
 	_, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -1009,63 +1067,10 @@ func Delete(ctx context.Context, c *client.Client, nodeName, volumeName string)
 	return nil
 }
 
-// Logs retrieves logs from a pod
-func Logs(ctx context.Context, c *client.Client, podName string, opts LogsOptions) (string, error) {
-	podLogOpts := &corev1.PodLogOptions{
-		Follow: opts.Follow,
-	}
-	if opts.Tail > 0 {
-		podLogOpts.TailLines = &opts.Tail
-	}
-
-	req := c.Clientset.CoreV1().Pods(c.Namespace).GetLogs(podName, podLogOpts)
-	stream, err := req.Stream(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get logs: %w", err)
-	}
-	defer stream.Close()
-
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, stream)
-	if err != nil {
-		return "", fmt.Errorf("failed to read logs: %w", err)
-	}
-
-	return buf.String(), nil
-}
-
 // WaitForPodReady waits for a pod to be ready
 func WaitForPodReady(ctx context.Context, c *client.Client, podName string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to get pod: %w", err)
-		}
-
-		if pod.Status.Phase == corev1.PodRunning {
-			// Check if container is ready
-			for _, cs := range pod.Status.ContainerStatuses {
-				if cs.Ready {
-					return nil
-				}
-			}
-		}
-
-		if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
-			return fmt.Errorf("pod ended with status: %s", pod.Status.Phase)
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Second):
-			// continue polling
-		}
-	}
-
-	return fmt.Errorf("timeout waiting for pod to be ready")
+	_, err := WaitForPod(ctx, c, podName, timeout, PodReady)
+	return timeoutMessage(err, "timeout waiting for pod to be ready")
 }
 
 // Attach attaches to a running pod with an interactive shell
@@ -1097,6 +1102,13 @@ func Attach(ctx context.Context, c *client.Client, podName string, stdin io.Read
 	})
 }
 
+// Exec runs a non-interactive command in a session pod.
+// Stdout and stderr are streamed separately; stdin is forwarded only if non-nil.
+// If the command exits non-zero, the returned error is a k8s.io/client-go/util/exec.ExitError.
+func Exec(ctx context.Context, c *client.Client, podName string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return execInPod(ctx, c, podName, command, stdin, stdout, stderr)
+}
+
 // CopyOptions holds options for copying a volume
 type CopyOptions struct {
 	SrcNode   string
@@ -1105,6 +1117,10 @@ type CopyOptions struct {
 	DstNode   string
 	DstVolume string
 	DstPath   string // Empty for volume copy (entire volume)
+	Verify    bool   // Compare file checksums after the copy
+	Compress  string // Compression for cross-node copies: "", gzip or zstd
+	BwLimit   int64  // Bandwidth limit for cross-node copies in bytes per second (0 = unlimited)
+	Relay     bool   // Relay cross-node copies through sgs instead of pod to pod
 }
 
 // validateNodeAccess checks if the current workspace can access a specific node
@@ -1178,7 +1194,7 @@ func Copy(ctx context.Context, c *client.Client, opts CopyOptions) error {
 	fmt.Printf("Creating destination volume %s/%s (%s)...\n", opts.DstNode, opts.DstVolume, srcInfo.Size)
 
 	// Create destination PVC (without init)
-	dstPVCName := pvcName(opts.DstNode, opts.DstVolume)
+	dstPVCName := PVCName(opts.DstNode, opts.DstVolume)
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      dstPVCName,
@@ -1223,14 +1239,14 @@ func Copy(ctx context.Context, c *client.Client, opts CopyOptions) error {
 	})
 
 	// Perform copy based on whether source and destination are on the same node
-	srcPVCName := pvcName(opts.SrcNode, opts.SrcVolume)
+	srcPVCName := PVCName(opts.SrcNode, opts.SrcVolume)
 
 	if opts.SrcNode == opts.DstNode {
 		// Same node: create single pod with both volumes
-		err = copySameNode(ctx, c, opts.SrcNode, srcPVCName, dstPVCName)
+		err = copySameNode(ctx, c, opts.SrcNode, srcPVCName, dstPVCName, opts.Verify)
 	} else {
 		// Different nodes: stream via tar between two pods
-		err = copyCrossNode(ctx, c, opts.SrcNode, opts.DstNode, srcPVCName, dstPVCName)
+		err = copyCrossNode(ctx, c, opts.SrcNode, opts.DstNode, srcPVCName, dstPVCName, opts.transferOptions())
 	}
 
 	if err != nil {
@@ -1255,10 +1271,18 @@ func Copy(ctx context.Context, c *client.Client, opts CopyOptions) error {
 }
 
 // copySameNode copies volume contents on the same node using a single pod
-func copySameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, dstPVC string) error {
-	podName := "copy-" + dstPVC
+func copySameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, dstPVC string, verify bool) error {
 	fmt.Println("Copying volume contents (same node)...")
+	script := "cp -a /src/. /dst/"
+	if verify {
+		script += " && " + verifyInPodScript("/src", ".", "/dst")
+	}
+	return runCopyPod(ctx, c, "copy-"+dstPVC, nodeName, srcPVC, dstPVC, script+" && echo 'Copy complete'")
+}
 
+// runCopyPod runs script in a pod on nodeName with srcPVC mounted read-only at /src
+// and dstPVC at /dst, and waits for it to complete. The pod is always deleted.
+func runCopyPod(ctx context.Context, c *client.Client, podName, nodeName, srcPVC, dstPVC, script string) error {
 	// Create copy pod with both volumes mounted
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1292,7 +1316,7 @@ func copySameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, dstPV
 						{Name: "dst", MountPath: "/dst"},
 					},
 					Command: []string{"/bin/sh", "-c"},
-					Args:    []string{"cp -a /src/. /dst/ && echo 'Copy complete'"},
+					Args:    []string{script},
 				},
 			},
 			Volumes: []corev1.Volume{
@@ -1337,27 +1361,42 @@ func copySameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, dstPV
 	}()
 
 	// Wait for pod to complete
-	return waitForCopyPod(ctx, c, podName, 30*time.Minute)
+	if err := waitForCopyPod(ctx, c, podName, 30*time.Minute); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return withCopyPodLogs(c, podName, err)
+	}
+	return nil
 }
 
-// progressWriter wraps an io.Writer and tracks bytes written
+// progressWriter wraps an io.Writer and tracks bytes written.
+// If hash is set, the bytes written are also added to it. If limit is set,
+// writes are slowed down to keep the average rate since start below it.
 type progressWriter struct {
 	writer  io.Writer
 	written int64
 	onWrite func(int64)
+	hash    hash.Hash
+	limit   int64 // Bytes per second
+	start   time.Time
 }
 
 func (pw *progressWriter) Write(p []byte) (n int, err error) {
 	n, err = pw.writer.Write(p)
 	pw.written += int64(n)
+	if pw.hash != nil {
+		pw.hash.Write(p[:n])
+	}
+	pw.throttle()
 	if pw.onWrite != nil {
 		pw.onWrite(pw.written)
 	}
 	return
 }
 
-// formatBytes formats bytes into human-readable format
-func formatBytes(bytes int64) string {
+// FormatBytes formats bytes into human-readable format
+func FormatBytes(bytes int64) string {
 	const (
 		KB = 1024
 		MB = KB * 1024
@@ -1377,7 +1416,7 @@ func formatBytes(bytes int64) string {
 }
 
 // copyCrossNode copies volume contents between different nodes using tar stream
-func copyCrossNode(ctx context.Context, c *client.Client, srcNode, dstNode, srcPVC, dstPVC string) error {
+func copyCrossNode(ctx context.Context, c *client.Client, srcNode, dstNode, srcPVC, dstPVC string, opts transferOptions) error {
 	srcPodName := "copy-src-" + srcPVC
 	dstPodName := "copy-dst-" + dstPVC
 
@@ -1385,6 +1424,7 @@ func copyCrossNode(ctx context.Context, c *client.Client, srcNode, dstNode, srcP
 
 	// Create source reader pod (no subPath - copy entire PVC for volume copy)
 	srcPod := createCopyPod(srcPodName, srcNode, srcPVC, c.Namespace, true, false)
+	useCompression(srcPod, opts.compress)
 	_, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, srcPod, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create source pod: %w", err)
@@ -1405,6 +1445,7 @@ func copyCrossNode(ctx context.Context, c *client.Client, srcNode, dstNode, srcP
 
 	// Create destination writer pod (no subPath - copy entire PVC for volume copy)
 	dstPod := createCopyPod(dstPodName, dstNode, dstPVC, c.Namespace, false, false)
+	useCompression(dstPod, opts.compress)
 	_, err = c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, dstPod, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create destination pod: %w", err)
@@ -1433,68 +1474,30 @@ func copyCrossNode(ctx context.Context, c *client.Client, srcNode, dstNode, srcP
 		fmt.Println(" failed")
 		return fmt.Errorf("destination pod failed to start: %w", err)
 	}
+	for _, podName := range []string{srcPodName, dstPodName} {
+		if err := checkCompression(ctx, c, podName, opts.compress); err != nil {
+			fmt.Println(" failed")
+			return err
+		}
+	}
 	fmt.Println(" done")
 
-	// Stream data: tar from source | tar to destination
-	fmt.Println("  Streaming data between nodes...")
-
-	// Use a pipe to connect tar output to tar input with progress tracking
-	pr, pw := io.Pipe()
-
-	// Progress tracking
-	var progressMu sync.Mutex
-	var lastPrinted int64
-	progress := &progressWriter{
-		writer: pw,
-		onWrite: func(total int64) {
-			progressMu.Lock()
-			defer progressMu.Unlock()
-			// Update every 1MB to avoid too frequent updates
-			if total-lastPrinted >= 1024*1024 {
-				fmt.Printf("\r  Transferred: %s", formatBytes(total))
-				lastPrinted = total
-			}
-		},
+	if err := transferStream(ctx, c, srcPodName, "/data", dstPodName, "/data", opts); err != nil {
+		return err
 	}
 
-	// Capture stderr for error messages
-	var srcStderr, dstStderr bytes.Buffer
-
-	errChan := make(chan error, 2)
-
-	// Source: tar cf - /data
-	go func() {
-		defer pw.Close()
-		err := execInPod(ctx, c, srcPodName, []string{"tar", "cf", "-", "-C", "/data", "."}, nil, progress, &srcStderr)
-		if err != nil && srcStderr.Len() > 0 {
-			err = fmt.Errorf("%w: %s", err, srcStderr.String())
-		}
-		errChan <- err
-	}()
-
-	// Destination: tar xf - -C /data
-	go func() {
-		err := execInPod(ctx, c, dstPodName, []string{"tar", "xf", "-", "-C", "/data"}, pr, nil, &dstStderr)
-		if err != nil && dstStderr.Len() > 0 {
-			err = fmt.Errorf("%w: %s", err, dstStderr.String())
-		}
-		errChan <- err
-	}()
-
-	// Wait for both to complete
-	for i := 0; i < 2; i++ {
-		if err := <-errChan; err != nil {
-			return fmt.Errorf("copy stream failed: %w", err)
-		}
+	if opts.verify {
+		return verifyFiles(ctx, c, srcPodName, "/data", dstPodName, "/data")
 	}
 
-	// Print final progress
-	fmt.Printf("\r  Transferred: %s\n", formatBytes(progress.written))
-
 	return nil
 }
 
-// createCopyPod creates a pod for cross-node copy (long-running sleep).
+// keepAliveScript keeps a helper pod running until it is deleted, however long
+// the commands exec'd in it take. Waiting on sleep lets it exit on SIGTERM.
+const keepAliveScript = "trap 'exit 0' TERM; while :; do sleep 3600 & wait $!; done"
+
+// createCopyPod creates a pod for cross-node copy (kept alive until deleted).
 // Uses subPath: "upper" for OS volumes to expose only the user's filesystem.
 func createCopyPod(name, nodeName, pvcName, namespace string, readOnly, isOSVolume bool) *corev1.Pod {
 	mount := corev1.VolumeMount{Name: "data", MountPath: "/data", ReadOnly: readOnly}
@@ -1531,7 +1534,7 @@ func createCopyPod(name, nodeName, pvcName, namespace string, readOnly, isOSVolu
 					},
 					VolumeMounts: []corev1.VolumeMount{mount},
 					Command:      []string{"/bin/sh", "-c"},
-					Args:         []string{"sleep 3600"}, // Stay alive for exec
+					Args:         []string{keepAliveScript}, // Stay alive for exec
 				},
 			},
 			Volumes: []corev1.Volume{
@@ -1552,100 +1555,42 @@ func createCopyPod(name, nodeName, pvcName, namespace string, readOnly, isOSVolu
 
 // waitForCopyPod waits for the copy pod to complete
 func waitForCopyPod(ctx context.Context, c *client.Client, podName string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-
 	// Spinner characters for progress indication
 	spinChars := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	spinIdx := 0
 	startTime := time.Now()
 
-	for time.Now().Before(deadline) {
-		pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
-		if err != nil {
-			fmt.Print("\r                              \r") // Clear spinner line
-			return fmt.Errorf("failed to get copy pod: %w", err)
-		}
-
-		elapsed := time.Since(startTime).Round(time.Second)
+	_, err := waitForPod(ctx, c, podName, timeout, func(pod *corev1.Pod) (bool, error) {
 		switch pod.Status.Phase {
 		case corev1.PodSucceeded:
-			fmt.Printf("\r  Copying... done (%s)       \n", elapsed)
-			return nil
+			return true, nil
 		case corev1.PodFailed:
-			fmt.Print("\r                              \r") // Clear spinner line
-			return fmt.Errorf("copy pod failed")
+			return false, fmt.Errorf("copy pod failed")
+		}
+		return false, imagePullError(pod)
+	}, func(pod *corev1.Pod) {
+		elapsed := time.Since(startTime).Round(time.Second)
+		switch pod.Status.Phase {
 		case corev1.PodPending:
-			// Check for errors
-			for _, cs := range pod.Status.ContainerStatuses {
-				if cs.State.Waiting != nil {
-					if cs.State.Waiting.Reason == "ImagePullBackOff" || cs.State.Waiting.Reason == "ErrImagePull" {
-						fmt.Print("\r                              \r") // Clear spinner line
-						return fmt.Errorf("failed to pull image: %s", cs.State.Waiting.Message)
-					}
-				}
-			}
 			fmt.Printf("\r  %s Waiting for copy pod to start... (%s)", spinChars[spinIdx], elapsed)
 		case corev1.PodRunning:
 			fmt.Printf("\r  %s Copying... (%s)", spinChars[spinIdx], elapsed)
 		}
 		spinIdx = (spinIdx + 1) % len(spinChars)
-
-		select {
-		case <-ctx.Done():
-			fmt.Print("\r                              \r") // Clear spinner line
-			return ctx.Err()
-		case <-time.After(200 * time.Millisecond):
-		}
+	})
+	if err != nil {
+		fmt.Print("\r                              \r") // Clear spinner line
+		return timeoutMessage(err, "timeout waiting for copy to complete")
 	}
 
-	fmt.Print("\r                              \r") // Clear spinner line
-	return fmt.Errorf("timeout waiting for copy to complete")
+	fmt.Printf("\r  Copying... done (%s)       \n", time.Since(startTime).Round(time.Second))
+	return nil
 }
 
 // waitForPodRunning waits for a pod to be running
 func waitForPodRunning(ctx context.Context, c *client.Client, podName string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		// Check context first
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, podName, metav1.GetOptions{})
-		if err != nil {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-			return fmt.Errorf("failed to get pod: %w", err)
-		}
-
-		if pod.Status.Phase == corev1.PodRunning {
-			return nil
-		}
-		if pod.Status.Phase == corev1.PodFailed {
-			return fmt.Errorf("pod failed")
-		}
-
-		// Check for pending errors
-		for _, cs := range pod.Status.ContainerStatuses {
-			if cs.State.Waiting != nil {
-				if cs.State.Waiting.Reason == "ImagePullBackOff" || cs.State.Waiting.Reason == "ErrImagePull" {
-					return fmt.Errorf("failed to pull image: %s", cs.State.Waiting.Message)
-				}
-			}
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(500 * time.Millisecond):
-		}
-	}
-
-	return fmt.Errorf("timeout waiting for pod to start")
+	_, err := WaitForPod(ctx, c, podName, timeout, PodRunning)
+	return timeoutMessage(err, "timeout waiting for pod to start")
 }
 
 // execInPod executes a command in a pod with stdin/stdout/stderr
@@ -1710,8 +1655,8 @@ func CopyFiles(ctx context.Context, c *client.Client, opts CopyOptions) error {
 		return fmt.Errorf("destination volume %s/%s has an active session, please delete it first", opts.DstNode, opts.DstVolume)
 	}
 
-	srcPVCName := pvcName(opts.SrcNode, opts.SrcVolume)
-	dstPVCName := pvcName(opts.DstNode, opts.DstVolume)
+	srcPVCName := PVCName(opts.SrcNode, opts.SrcVolume)
+	dstPVCName := PVCName(opts.DstNode, opts.DstVolume)
 
 	// Normalize paths (remove leading slash)
 	srcPath := strings.TrimPrefix(opts.SrcPath, "/")
@@ -1719,15 +1664,15 @@ func CopyFiles(ctx context.Context, c *client.Client, opts CopyOptions) error {
 
 	if opts.SrcNode == opts.DstNode {
 		// Same node: single pod with both volumes (uses subPath for OS volumes)
-		return copyPathSameNode(ctx, c, opts.SrcNode, srcPVCName, srcPath, srcInfo.IsOSVolume, dstPVCName, dstPath, dstInfo.IsOSVolume)
+		return copyPathSameNode(ctx, c, opts.SrcNode, srcPVCName, srcPath, srcInfo.IsOSVolume, dstPVCName, dstPath, dstInfo.IsOSVolume, opts.Verify)
 	}
 	// Different nodes: stream between pods (uses subPath for OS volumes)
-	return copyPathCrossNode(ctx, c, opts.SrcNode, srcPVCName, srcPath, srcInfo.IsOSVolume, opts.DstNode, dstPVCName, dstPath, dstInfo.IsOSVolume)
+	return copyPathCrossNode(ctx, c, opts.SrcNode, srcPVCName, srcPath, srcInfo.IsOSVolume, opts.DstNode, dstPVCName, dstPath, dstInfo.IsOSVolume, opts.transferOptions())
 }
 
 // copyPathSameNode copies a specific path on the same node using a single pod.
 // Uses subPath: "upper" for OS volumes to expose only the user's filesystem.
-func copyPathSameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, srcPath string, srcIsOS bool, dstPVC, dstPath string, dstIsOS bool) error {
+func copyPathSameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, srcPath string, srcIsOS bool, dstPVC, dstPath string, dstIsOS bool, verify bool) error {
 	podName := "copy-path-" + dstPVC
 	fmt.Printf("Copying %s to %s (same node)...\n", srcPath, dstPath)
 
@@ -1738,6 +1683,11 @@ func copyPathSameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, s
 		srcPath,
 		dstPath,
 	)
+	if verify {
+		// cp -a puts the source inside the destination directory
+		src := path.Clean("/src/" + srcPath)
+		copyCmd += " && " + verifyInPodScript(path.Dir(src), path.Base(src), "/dst/"+dstPath)
+	}
 
 	// Build volume mounts with subPath for OS volumes
 	srcMount := corev1.VolumeMount{Name: "src", MountPath: "/src", ReadOnly: true}
@@ -1827,7 +1777,10 @@ func copyPathSameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, s
 
 	// Wait for pod to complete
 	if err := waitForCopyPod(ctx, c, podName, 30*time.Minute); err != nil {
-		return err
+		if ctx.Err() != nil {
+			return err
+		}
+		return withCopyPodLogs(c, podName, err)
 	}
 
 	return nil
@@ -1837,7 +1790,7 @@ func copyPathSameNode(ctx context.Context, c *client.Client, nodeName, srcPVC, s
 // Uses subPath: "upper" for OS volumes to expose only the user's filesystem.
 func copyPathCrossNode(ctx context.Context, c *client.Client,
 	srcNode, srcPVC, srcPath string, srcIsOS bool,
-	dstNode, dstPVC, dstPath string, dstIsOS bool) error {
+	dstNode, dstPVC, dstPath string, dstIsOS bool, opts transferOptions) error {
 
 	srcPodName := "copy-src-" + srcPVC
 	dstPodName := "copy-dst-" + dstPVC
@@ -1845,6 +1798,7 @@ func copyPathCrossNode(ctx context.Context, c *client.Client,
 
 	// Create source pod (uses subPath for OS volumes)
 	srcPod := createCopyPod(srcPodName, srcNode, srcPVC, c.Namespace, true, srcIsOS)
+	useCompression(srcPod, opts.compress)
 	_, err := c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, srcPod, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create source pod: %w", err)
@@ -1866,6 +1820,7 @@ func copyPathCrossNode(ctx context.Context, c *client.Client,
 
 	// Create destination pod (uses subPath for OS volumes)
 	dstPod := createCopyPod(dstPodName, dstNode, dstPVC, c.Namespace, false, dstIsOS)
+	useCompression(dstPod, opts.compress)
 	_, err = c.Clientset.CoreV1().Pods(c.Namespace).Create(ctx, dstPod, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create destination pod: %w", err)
@@ -1895,6 +1850,12 @@ func copyPathCrossNode(ctx context.Context, c *client.Client,
 		fmt.Println(" failed")
 		return fmt.Errorf("destination pod failed to start: %w", err)
 	}
+	for _, podName := range []string{srcPodName, dstPodName} {
+		if err := checkCompression(ctx, c, podName, opts.compress); err != nil {
+			fmt.Println(" failed")
+			return err
+		}
+	}
 	fmt.Println(" done")
 
 	// First, create destination directory
@@ -1905,56 +1866,12 @@ func copyPathCrossNode(ctx context.Context, c *client.Client,
 		return fmt.Errorf("failed to create destination directory: %w: %s", err, mkdirStderr.String())
 	}
 
-	// Stream data: tar from source path | tar to destination path
-	fmt.Println("  Streaming data between nodes...")
-
-	pr, pw := io.Pipe()
-
-	// Progress tracking
-	var progressMu sync.Mutex
-	var lastPrinted int64
-	progress := &progressWriter{
-		writer: pw,
-		onWrite: func(total int64) {
-			progressMu.Lock()
-			defer progressMu.Unlock()
-			if total-lastPrinted >= 1024*1024 {
-				fmt.Printf("\r  Transferred: %s", formatBytes(total))
-				lastPrinted = total
-			}
-		},
+	if err := transferStream(ctx, c, srcPodName, "/data/"+srcPath, dstPodName, "/data/"+dstPath, opts); err != nil {
+		return err
 	}
 
-	var srcStderr, dstStderr bytes.Buffer
-	errChan := make(chan error, 2)
-
-	// Source: tar from the specific path
-	go func() {
-		defer pw.Close()
-		// tar cf - -C /data/<srcPath> . to tar contents of the source path
-		err := execInPod(ctx, c, srcPodName, []string{"tar", "cf", "-", "-C", "/data/" + srcPath, "."}, nil, progress, &srcStderr)
-		if err != nil && srcStderr.Len() > 0 {
-			err = fmt.Errorf("%w: %s", err, srcStderr.String())
-		}
-		errChan <- err
-	}()
-
-	// Destination: extract to the specific path
-	go func() {
-		err := execInPod(ctx, c, dstPodName, []string{"tar", "xf", "-", "-C", "/data/" + dstPath}, pr, nil, &dstStderr)
-		if err != nil && dstStderr.Len() > 0 {
-			err = fmt.Errorf("%w: %s", err, dstStderr.String())
-		}
-		errChan <- err
-	}()
-
-	// Wait for both to complete
-	for i := 0; i < 2; i++ {
-		if err := <-errChan; err != nil {
-			return fmt.Errorf("copy stream failed: %w", err)
-		}
+	if opts.verify {
+		return verifyFiles(ctx, c, srcPodName, "/data/"+srcPath, dstPodName, "/data/"+dstPath)
 	}
-
-	fmt.Printf("\r  Transferred: %s\n", formatBytes(progress.written))
 	return nil
 }
@@ -0,0 +1,168 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ErrWaitTimeout is returned by WaitForPod when the timeout elapses first
+var ErrWaitTimeout = errors.New("timed out")
+
+// PodCondition reports whether a pod has reached the awaited state. It returns
+// an error if the pod can no longer reach it (e.g. it failed or can't pull its image).
+type PodCondition func(pod *corev1.Pod) (bool, error)
+
+// PodRunning is satisfied once the pod is running
+func PodRunning(pod *corev1.Pod) (bool, error) {
+	if pod.Status.Phase == corev1.PodRunning {
+		return true, nil
+	}
+	return false, podRunningError(pod)
+}
+
+// PodReady is satisfied once the pod is running and a container is ready
+func PodReady(pod *corev1.Pod) (bool, error) {
+	if pod.Status.Phase == corev1.PodRunning {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Ready {
+				return true, nil
+			}
+		}
+	}
+	return false, podRunningError(pod)
+}
+
+// PodCompleted is satisfied once the pod has terminated, successfully or not
+func PodCompleted(pod *corev1.Pod) (bool, error) {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true, nil
+	}
+	return false, imagePullError(pod)
+}
+
+// podRunningError returns an error if a pod that should be running has ended or can't start
+func podRunningError(pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
+		return fmt.Errorf("pod ended with status: %s", pod.Status.Phase)
+	}
+	return imagePullError(pod)
+}
+
+// imagePullError returns an error if a container of the pod can't pull its image
+func imagePullError(pod *corev1.Pod) error {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil {
+			if cs.State.Waiting.Reason == "ImagePullBackOff" || cs.State.Waiting.Reason == "ErrImagePull" {
+				return fmt.Errorf("failed to pull image: %s", cs.State.Waiting.Message)
+			}
+		}
+	}
+	return nil
+}
+
+// timeoutMessage replaces ErrWaitTimeout with a message describing what was awaited
+func timeoutMessage(err error, message string) error {
+	if errors.Is(err, ErrWaitTimeout) {
+		return errors.New(message)
+	}
+	return err
+}
+
+// WaitForPod watches a pod until condition is satisfied, and returns the pod as
+// last seen. It returns ErrWaitTimeout if timeout (0 = none) elapses first.
+func WaitForPod(ctx context.Context, c *client.Client, podName string, timeout time.Duration, condition PodCondition) (*corev1.Pod, error) {
+	return waitForPod(ctx, c, podName, timeout, condition, nil)
+}
+
+// waitForPod is WaitForPod with an optional onTick callback, which is called
+// with the latest pod every 200ms (e.g. to animate a spinner)
+func waitForPod(ctx context.Context, c *client.Client, podName string, timeout time.Duration, condition PodCondition, onTick func(*corev1.Pod)) (*corev1.Pod, error) {
+	waitCtx, cancel := ctx, func() {}
+	if timeout > 0 {
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	// waitErr distinguishes the timeout from the caller cancelling ctx
+	waitErr := func() error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrWaitTimeout
+	}
+
+	opts := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", podName).String(),
+	}
+	pods, err := client.RetryWithContext(waitCtx, func() (*corev1.PodList, error) {
+		return c.Clientset.CoreV1().Pods(c.Namespace).List(waitCtx, opts)
+	})
+	if err != nil {
+		if waitCtx.Err() != nil {
+			return nil, waitErr()
+		}
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("pod %q not found", podName)
+	}
+
+	pod := &pods.Items[0]
+	if done, err := condition(pod); done || err != nil {
+		return pod, err
+	}
+
+	w, err := client.NewRetryWatcher(waitCtx, pods.ResourceVersion, opts, c.Clientset.CoreV1().Pods(c.Namespace).Watch)
+	if err != nil {
+		return pod, err
+	}
+	defer w.Stop()
+
+	var tick <-chan time.Time
+	if onTick != nil {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		tick = ticker.C
+		onTick(pod)
+	}
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			return pod, waitErr()
+
+		case <-tick:
+			onTick(pod)
+
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				if waitCtx.Err() != nil {
+					return pod, waitErr()
+				}
+				return pod, fmt.Errorf("watch on pod %q ended unexpectedly", podName)
+			}
+			switch event.Type {
+			case watch.Error:
+				return pod, client.FormatK8sError(apierrors.FromObject(event.Object), "watch", "pod", c.Namespace)
+			case watch.Deleted:
+				return pod, fmt.Errorf("pod %q was deleted", podName)
+			case watch.Added, watch.Modified:
+				if p, ok := event.Object.(*corev1.Pod); ok {
+					pod = p
+					if done, err := condition(pod); done || err != nil {
+						return pod, err
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacchus-snu/sgs-cli/internal/client"
+	"github.com/bacchus-snu/sgs-cli/internal/sgs"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Watch calls fn for every current volume (as watch.Added) and then for every
+// change to a volume until ctx is cancelled. Since a volume's status follows its
+// session, session pod changes are reported as watch.Modified on the volume.
+func Watch(ctx context.Context, c *client.Client, fn func(watch.EventType, VolumeInfo)) error {
+	pvcs, err := client.RetryWithContext(ctx, func() (*corev1.PersistentVolumeClaimList, error) {
+		return c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return client.FormatK8sError(err, "list", "volumes", c.Namespace)
+	}
+
+	// Only the resourceVersion is needed here, the pods are read per volume
+	sessionOpts := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=sgs,%s", sgs.LabelManagedBy, sgs.LabelSessionMode),
+	}
+	pods, err := client.RetryWithContext(ctx, func() (*corev1.PodList, error) {
+		return c.Clientset.CoreV1().Pods(c.Namespace).List(ctx, sessionOpts)
+	})
+	if err != nil {
+		return client.FormatK8sError(err, "list", "sessions", c.Namespace)
+	}
+
+	for i := range pvcs.Items {
+		fn(watch.Added, pvcToVolumeInfo(ctx, c, &pvcs.Items[i]))
+	}
+
+	pvcWatch, err := client.NewRetryWatcher(ctx, pvcs.ResourceVersion, metav1.ListOptions{}, c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Watch)
+	if err != nil {
+		return err
+	}
+	defer pvcWatch.Stop()
+
+	podWatch, err := client.NewRetryWatcher(ctx, pods.ResourceVersion, sessionOpts, c.Clientset.CoreV1().Pods(c.Namespace).Watch)
+	if err != nil {
+		return err
+	}
+	defer podWatch.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-pvcWatch.ResultChan():
+			if !ok {
+				return watchEnded(ctx, "volumes")
+			}
+			if event.Type == watch.Error {
+				return client.FormatK8sError(apierrors.FromObject(event.Object), "watch", "volumes", c.Namespace)
+			}
+			if pvc, ok := event.Object.(*corev1.PersistentVolumeClaim); ok {
+				fn(event.Type, pvcToVolumeInfo(ctx, c, pvc))
+			}
+
+		case event, ok := <-podWatch.ResultChan():
+			if !ok {
+				return watchEnded(ctx, "sessions")
+			}
+			if event.Type == watch.Error {
+				return client.FormatK8sError(apierrors.FromObject(event.Object), "watch", "sessions", c.Namespace)
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			// Session pods share the name of their PVC (<node>-<volume>)
+			pvc, err := c.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				continue // Volume deleted, or not a volume-backed session
+			}
+			fn(watch.Modified, pvcToVolumeInfo(ctx, c, pvc))
+		}
+	}
+}
+
+// watchEnded returns the error for a watch channel that was closed
+func watchEnded(ctx context.Context, resource string) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("watch on %s ended unexpectedly", resource)
+}
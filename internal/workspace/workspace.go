@@ -21,11 +21,11 @@ var FromNamespace = sgs.NamespaceToWorkspace
 
 // WorkspaceInfo represents information about an SGS workspace
 type WorkspaceInfo struct {
-	Name      string
-	NodeGroup string // from node selector annotation
-	GPUQuota  int64
-	CPUQuota  string
-	MemQuota  string
+	Name      string `json:"name" yaml:"name"`
+	NodeGroup string `json:"nodeGroup" yaml:"nodeGroup"` // from node selector annotation
+	GPUQuota  int64  `json:"gpuQuota" yaml:"gpuQuota"`
+	CPUQuota  string `json:"cpuQuota" yaml:"cpuQuota"`
+	MemQuota  string `json:"memQuota" yaml:"memQuota"`
 }
 
 // List returns all workspaces the user has access to